@@ -0,0 +1,29 @@
+package cbcolumnar
+
+import (
+	"time"
+)
+
+// AuditRecord encapsulates the details of a single query execution for the purposes of audit logging.
+type AuditRecord struct {
+	// Statement is the query statement that was executed.
+	// The statement is redacted as user data when log redaction is enabled.
+	Statement string
+
+	// User is the username that the query was executed as.
+	User string
+
+	// Timestamp is the time at which the query was executed.
+	Timestamp time.Time
+
+	// ClientContextID is the client context ID that was sent with the query.
+	ClientContextID string
+
+	// Dispatched indicates whether the query was sent to the server.
+	Dispatched bool
+
+	// Err is the error that the query failed with before any results were returned, if any.
+	// The record is created once the query has either failed or started returning results, so errors which occur
+	// whilst rows are being streamed are not included, these are returned by QueryResult.Err.
+	Err error
+}
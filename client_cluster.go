@@ -1,10 +1,11 @@
 package cbcolumnar
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/couchbase/gocbcore/v10"
@@ -14,26 +15,39 @@ import (
 type clusterClient interface {
 	QueryClient() queryClient
 	Database(name string) databaseClient
+	CancelByTag(key, value string) int
 
 	Close() error
 }
 
 type address struct {
-	Host string
-	Port int
+	Host    string
+	Port    int
+	FromSRV bool
 }
 
 type clusterClientOptions struct {
-	Spec                                 gocbconnstr.ConnSpec
-	Credential                           *Credential
-	ConnectTimeout                       time.Duration
-	ServerQueryTimeout                   time.Duration
-	TrustOnly                            TrustOnly
-	DisableServerCertificateVerification *bool
-	CipherSuites                         []*tls.CipherSuite
-	DisableSrv                           bool
-	Addresses                            []address
-	Unmarshaler                          Unmarshaler
+	Spec                     gocbconnstr.ConnSpec
+	Credential               *Credential
+	ConnectTimeout           time.Duration
+	ServerQueryTimeout       time.Duration
+	MinQueryTimeout          time.Duration
+	MaxQueryTimeout          time.Duration
+	DefaultOperationTimeout  time.Duration
+	SlowQueryThreshold       time.Duration
+	MaxErrorDescriptors      int
+	MaxConnsPerEndpoint      int
+	AdditionalRetriableCodes map[uint32]struct{}
+	TLSRootCAProvider        func() *x509.CertPool
+	CipherSuites             []*tls.CipherSuite
+	DisableSrv               bool
+	SRVService               string
+	SRVProto                 string
+	Addresses                []address
+	Unmarshaler              Unmarshaler
+	BaseContext              func() context.Context
+	AuditHook                func(AuditRecord)
+	DefaultNamespace         *NamespaceOptions
 }
 
 func newClusterClient(opts clusterClientOptions) (clusterClient, error) {
@@ -43,20 +57,14 @@ func newClusterClient(opts clusterClientOptions) (clusterClient, error) {
 type gocbcoreClusterClient struct {
 	agent *gocbcore.ColumnarAgent
 
-	serverQueryTimeout time.Duration
-	unmarshaler        Unmarshaler
+	queryConfig gocbcoreQueryClientConfig
 }
 
 func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient, error) {
 	addresses := make([]string, len(opts.Addresses))
 
 	for i, addr := range opts.Addresses {
-		port := addr.Port
-		if port == -1 {
-			port = 11207
-		}
-
-		addresses[i] = fmt.Sprintf("%s:%d", addr.Host, port)
+		addresses[i] = fmt.Sprintf("%s:%d", addr.Host, addr.Port)
 	}
 
 	var srvRecord *gocbcore.SRVRecord
@@ -68,67 +76,12 @@ func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient
 		}
 
 		srvRecord = &gocbcore.SRVRecord{
-			Proto:  "tcp",
-			Scheme: "couchbases",
+			Proto:  opts.SRVProto,
+			Scheme: opts.SRVService,
 			Host:   host,
 		}
 	}
 
-	trustOnly := opts.TrustOnly
-	if trustOnly == nil {
-		trustOnly = TrustOnlyCapella{}
-	}
-
-	var caProvider func() *x509.CertPool
-
-	switch to := trustOnly.(type) {
-	case TrustOnlyCapella:
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM(capellaRootCA)
-
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
-	case TrustOnlySystem:
-		pool, err := x509.SystemCertPool()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read system cert pool %w", err)
-		}
-
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
-	case TrustOnlyPemFile:
-		data, err := os.ReadFile(to.Path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read pem file %w", err)
-		}
-
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM(data)
-
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
-	case TrustOnlyPemString:
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM([]byte(to.Pem))
-
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
-	case TrustOnlyCertificates:
-		caProvider = func() *x509.CertPool {
-			return to.Certificates
-		}
-	}
-
-	if opts.DisableServerCertificateVerification != nil && *opts.DisableServerCertificateVerification {
-		caProvider = func() *x509.CertPool {
-			return nil
-		}
-	}
-
 	coreOpts := &gocbcore.ColumnarAgentConfig{
 		UserAgent:      Identifier(),
 		ConnectTimeout: opts.ConnectTimeout,
@@ -137,7 +90,7 @@ func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient
 			SRVRecord: srvRecord,
 		},
 		SecurityConfig: gocbcore.ColumnarSecurityConfig{
-			TLSRootCAProvider: caProvider,
+			TLSRootCAProvider: opts.TLSRootCAProvider,
 			CipherSuite:       opts.CipherSuites,
 			Auth: gocbcore.PasswordAuthProvider{
 				Username: opts.Credential.UsernamePassword.Username,
@@ -156,7 +109,7 @@ func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient
 		HTTPConfig: gocbcore.ColumnarHTTPConfig{
 			MaxIdleConns:          0,
 			MaxIdleConnsPerHost:   0,
-			MaxConnsPerHost:       0,
+			MaxConnsPerHost:       opts.MaxConnsPerEndpoint,
 			IdleConnectionTimeout: 1 * time.Second,
 		},
 	}
@@ -166,22 +119,51 @@ func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient
 		return nil, fmt.Errorf("failed to create agent: %s", err) // nolint: err113, errorlint
 	}
 
+	var defaultNamespace *gocbcoreQueryClientNamespace
+	if opts.DefaultNamespace != nil {
+		defaultNamespace = &gocbcoreQueryClientNamespace{
+			Database: opts.DefaultNamespace.Database,
+			Scope:    opts.DefaultNamespace.Scope,
+		}
+	}
+
 	return &gocbcoreClusterClient{
-		agent:              agent,
-		serverQueryTimeout: opts.ServerQueryTimeout,
-		unmarshaler:        opts.Unmarshaler,
+		agent: agent,
+		queryConfig: gocbcoreQueryClientConfig{
+			defaultQueryTimeout:      opts.ServerQueryTimeout,
+			minQueryTimeout:          opts.MinQueryTimeout,
+			maxQueryTimeout:          opts.MaxQueryTimeout,
+			defaultOperationTimeout:  opts.DefaultOperationTimeout,
+			slowQueryThreshold:       opts.SlowQueryThreshold,
+			maxErrorDescriptors:      opts.MaxErrorDescriptors,
+			additionalRetriableCodes: opts.AdditionalRetriableCodes,
+			defaultUnmarshaler:       opts.Unmarshaler,
+			baseContext:              opts.BaseContext,
+			auditHook:                opts.AuditHook,
+			username:                 opts.Credential.UsernamePassword.Username,
+			defaultNamespace:         defaultNamespace,
+			inFlight:                 newInFlightQueries(),
+			closed:                   &atomic.Bool{},
+			now:                      time.Now,
+		},
 	}, nil
 }
 
 func (c *gocbcoreClusterClient) Database(name string) databaseClient {
-	return newGocbcoreDatabaseClient(c.agent, name, c.serverQueryTimeout, c.unmarshaler)
+	return newGocbcoreDatabaseClient(c.agent, name, c.queryConfig)
+}
+
+func (c *gocbcoreClusterClient) CancelByTag(key, value string) int {
+	return c.queryConfig.inFlight.cancelByTag(key, value)
 }
 
 func (c *gocbcoreClusterClient) QueryClient() queryClient {
-	return newGocbcoreQueryClient(c.agent, c.serverQueryTimeout, c.unmarshaler, nil)
+	return newGocbcoreQueryClient(c.agent, c.queryConfig, nil)
 }
 
 func (c *gocbcoreClusterClient) Close() error {
+	c.queryConfig.closed.Store(true)
+
 	err := c.agent.Close()
 	if err != nil {
 		return fmt.Errorf("failed to close agent: %s", err) // nolint: err113, errorlint
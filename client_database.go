@@ -1,8 +1,6 @@
 package cbcolumnar
 
 import (
-	"time"
-
 	"github.com/couchbase/gocbcore/v10"
 )
 
@@ -12,18 +10,16 @@ type databaseClient interface {
 }
 
 type gocbcoreDatabaseClient struct {
-	agent                     *gocbcore.ColumnarAgent
-	name                      string
-	defaultServerQueryTimeout time.Duration
-	defaultUnmarshaler        Unmarshaler
+	agent       *gocbcore.ColumnarAgent
+	name        string
+	queryConfig gocbcoreQueryClientConfig
 }
 
-func newGocbcoreDatabaseClient(agent *gocbcore.ColumnarAgent, name string, defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreDatabaseClient {
+func newGocbcoreDatabaseClient(agent *gocbcore.ColumnarAgent, name string, queryConfig gocbcoreQueryClientConfig) *gocbcoreDatabaseClient {
 	return &gocbcoreDatabaseClient{
-		agent:                     agent,
-		name:                      name,
-		defaultServerQueryTimeout: defaultServerQueryTimeout,
-		defaultUnmarshaler:        defaultUnmarshaler,
+		agent:       agent,
+		name:        name,
+		queryConfig: queryConfig,
 	}
 }
 
@@ -32,5 +28,5 @@ func (c *gocbcoreDatabaseClient) Name() string {
 }
 
 func (c *gocbcoreDatabaseClient) Scope(name string) scopeClient {
-	return newGocbcoreScopeClient(c.agent, name, c.name, c.defaultServerQueryTimeout, c.defaultUnmarshaler)
+	return newGocbcoreScopeClient(c.agent, name, c.name, c.queryConfig)
 }
@@ -2,10 +2,16 @@ package cbcolumnar
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http/httptrace"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,51 +27,308 @@ type gocbcoreQueryClientNamespace struct {
 	Database string
 	Scope    string
 }
+
+func (ns *gocbcoreQueryClientNamespace) QueryContext() string {
+	return "default:" + EscapeIdentifier(ns.Database) + "." + EscapeIdentifier(ns.Scope)
+}
+
+type gocbcoreQueryClientConfig struct {
+	defaultQueryTimeout      time.Duration
+	minQueryTimeout          time.Duration
+	maxQueryTimeout          time.Duration
+	defaultOperationTimeout  time.Duration
+	slowQueryThreshold       time.Duration
+	maxErrorDescriptors      int
+	additionalRetriableCodes map[uint32]struct{}
+	defaultUnmarshaler       Unmarshaler
+	baseContext              func() context.Context
+	auditHook                func(AuditRecord)
+	username                 string
+	defaultNamespace         *gocbcoreQueryClientNamespace
+	inFlight                 *inFlightQueries
+
+	// closed is shared by every query client created from the same cluster, and is set once the cluster is closed.
+	closed *atomic.Bool
+
+	// now returns the current time, it is only overridden within tests.
+	now func() time.Time
+}
+
+// columnarAgent is the subset of gocbcore.ColumnarAgent used by gocbcoreQueryClient.
+type columnarAgent interface {
+	Query(ctx context.Context, opts gocbcore.ColumnarQueryOptions) (*gocbcore.ColumnarRowReader, error)
+}
+
 type gocbcoreQueryClient struct {
-	agent               *gocbcore.ColumnarAgent
-	defaultQueryTimeout time.Duration
-	defaultUnmarshaler  Unmarshaler
-	namespace           *gocbcoreQueryClientNamespace
+	gocbcoreQueryClientConfig
+
+	agent     columnarAgent
+	namespace *gocbcoreQueryClientNamespace
 }
 
-func newGocbcoreQueryClient(agent *gocbcore.ColumnarAgent, defaultQueryTimeout time.Duration,
-	defaultUnmarshaler Unmarshaler, namespace *gocbcoreQueryClientNamespace) *gocbcoreQueryClient {
+func newGocbcoreQueryClient(agent columnarAgent, config gocbcoreQueryClientConfig,
+	namespace *gocbcoreQueryClientNamespace) *gocbcoreQueryClient {
 	return &gocbcoreQueryClient{
-		agent:               agent,
-		defaultQueryTimeout: defaultQueryTimeout,
-		defaultUnmarshaler:  defaultUnmarshaler,
-		namespace:           namespace,
+		gocbcoreQueryClientConfig: config,
+		agent:                     agent,
+		namespace:                 namespace,
 	}
 }
 
 func (c *gocbcoreQueryClient) Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
+	if c.closed != nil && c.closed.Load() {
+		return nil, newColumnarError(statement, "", 0).
+			withMessage("cluster has been closed").
+			withCause(ErrClusterClosed)
+	}
+
+	ctx = c.parentContext(ctx)
+
+	// Restarted queries must not use the context of this query, which is canceled once this query is done.
+	restartCtx := ctx
+
+	cancelTimeout := func() {}
+	if _, ok := ctx.Deadline(); !ok && c.defaultOperationTimeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, c.defaultOperationTimeout)
+	}
+
 	coreOpts, err := c.translateQueryOptions(ctx, statement, opts)
 	if err != nil {
+		cancelTimeout()
+
 		return nil, err
 	}
 
 	if c.namespace != nil {
-		coreOpts.Payload["query_context"] = fmt.Sprintf("default:`%s`.`%s`", c.namespace.Database, c.namespace.Scope)
+		coreOpts.Payload["query_context"] = c.namespace.QueryContext()
+	} else if _, ok := coreOpts.Payload["query_context"]; !ok && c.defaultNamespace != nil {
+		coreOpts.Payload["query_context"] = c.defaultNamespace.QueryContext()
 	}
 
-	coreOpts.Payload["client_context_id"] = uuid.NewString()
+	if _, ok := coreOpts.Payload["query_context"]; !ok && opts.DetectMissingQueryContext != nil &&
+		*opts.DetectMissingQueryContext {
+		if collection, found := unqualifiedCollectionReference(statement); found {
+			cancelTimeout()
+
+			return nil, invalidArgumentError{
+				ArgumentName: "statement",
+				Reason: fmt.Sprintf("statement appears to reference the unqualified collection %s but no query "+
+					"context is set, use Scope.ExecuteQuery or qualify the collection with its database and scope",
+					collection),
+			}
+		}
+	}
+
+	queryContext, _ := coreOpts.Payload["query_context"].(string)
+	if queryContext != "" && globalLogRedactionLevel != RedactNone {
+		queryContext = redactUserDataString(queryContext)
+	}
+
+	clientContextID := uuid.NewString()
+	coreOpts.Payload["client_context_id"] = clientContextID
+
+	// The context must remain valid for as long as rows are being streamed, so it is only cancelled
+	// once the query fails or the row reader is done.
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	done := func() {
+		cancel(nil)
+		cancelTimeout()
+	}
+
+	if c.inFlight != nil && len(opts.Tags) > 0 {
+		remove := c.inFlight.add(opts.Tags, cancel)
+		cancelQuery := done
+		done = func() {
+			remove()
+			cancelQuery()
+		}
+	}
 
-	res, err := c.agent.Query(ctx, *coreOpts)
+	if opts.ConnectTimeout != nil {
+		connectTimer := time.AfterFunc(*opts.ConnectTimeout, func() {
+			cancel(ErrConnectTimeout)
+		})
+		defer connectTimer.Stop()
+
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotConn: func(httptrace.GotConnInfo) {
+				connectTimer.Stop()
+			},
+		})
+	}
+
+	handshakeErrs := &tlsHandshakeRecorder{
+		lock: sync.Mutex{},
+		err:  nil,
+	}
+
+	endpoints := &endpointRecorder{
+		lock:      sync.Mutex{},
+		endpoints: nil,
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, newQueryClientTrace(endpoints, handshakeErrs))
+
+	startTime := c.now()
+
+	if c.slowQueryThreshold > 0 {
+		finishQuery := done
+		done = func() {
+			finishQuery()
+			c.logIfSlow(statement, clientContextID, startTime)
+		}
+	}
+
+	res, err := c.queryWithAdditionalRetries(ctx, *coreOpts)
 	if err != nil {
-		return nil, translateGocbcoreError(err)
+		var dispatched bool
+
+		dispatched, err = c.translateQueryError(ctx, statement, err, handshakeErrs)
+
+		done()
+
+		c.audit(statement, clientContextID, startTime, dispatched, err)
+
+		return nil, err
 	}
 
+	c.audit(statement, clientContextID, startTime, true, nil)
+
 	unmarshaler := opts.Unmarshaler
 	if unmarshaler == nil {
-		unmarshaler = c.defaultUnmarshaler
+		if ctxUnmarshaler, ok := UnmarshalerFromContext(ctx); ok {
+			unmarshaler = ctxUnmarshaler
+		} else {
+			unmarshaler = c.defaultUnmarshaler
+		}
+	}
+
+	var rowReadTimeout time.Duration
+	if opts.RowReadTimeout != nil {
+		rowReadTimeout = *opts.RowReadTimeout
+	}
+
+	var projectFields map[string]struct{}
+	if len(opts.ProjectFields) > 0 {
+		projectFields = make(map[string]struct{}, len(opts.ProjectFields))
+
+		for _, field := range opts.ProjectFields {
+			projectFields[field] = struct{}{}
+		}
+	}
+
+	var observedKeys map[string]struct{}
+	if opts.TrackObservedKeys != nil && *opts.TrackObservedKeys {
+		observedKeys = make(map[string]struct{})
+	}
+
+	var maxRowBytes int
+	if opts.MaxRowBytes != nil {
+		maxRowBytes = *opts.MaxRowBytes
+	}
+
+	var reader RowReader = c.newRowReader(ctx, res, done, cancel, rowReadTimeout, queryContext, endpoints)
+
+	if opts.AutoRestartOnConnectionLoss != nil && *opts.AutoRestartOnConnectionLoss {
+		restartOpts := *opts
+		restartOpts.AutoRestartOnConnectionLoss = nil
+
+		reader = newRestartingRowReader(reader, maxQueryRestarts, func() (RowReader, error) {
+			res, err := c.Query(restartCtx, statement, &restartOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			return res.reader, nil
+		})
 	}
 
 	return &QueryResult{
-		reader:      c.newRowReader(res),
-		unmarshaler: unmarshaler,
+		reader:           reader,
+		unmarshaler:      unmarshaler,
+		rowUnmarshalFunc: opts.RowUnmarshalFunc,
+		rowTransform:     opts.RowTransform,
+		rowFilter:        opts.RowFilter,
+		rowIndex:         0,
+
+		collapseDuplicates: opts.CollapseConsecutiveDuplicates != nil && *opts.CollapseConsecutiveDuplicates,
+		lastRow:            nil,
+
+		maxRowBytes:   maxRowBytes,
+		projectFields: projectFields,
+		observedKeys:  observedKeys,
+
+		treatWarningsAsErrors: opts.TreatWarningsAsErrors != nil && *opts.TreatWarningsAsErrors,
+		err:                   nil,
+
+		peekedRow: nil,
 	}, nil
 }
 
+func (c *gocbcoreQueryClient) logIfSlow(statement, clientContextID string, startTime time.Time) {
+	duration := c.now().Sub(startTime)
+	if duration <= c.slowQueryThreshold {
+		return
+	}
+
+	if globalLogRedactionLevel != RedactNone {
+		statement = redactUserDataString(statement)
+	}
+
+	logWarnf("Slow query took %s, exceeding threshold of %s (client_context_id: %s): %s",
+		duration, c.slowQueryThreshold, clientContextID, statement)
+}
+
+// parentContext returns the context to use as the parent of a query, substituting the context returned by
+// BaseContext when ctx is context.Background or context.TODO. Contexts derived from these are not substituted, as
+// they cannot be distinguished from any other context.
+func (c *gocbcoreQueryClient) parentContext(ctx context.Context) context.Context {
+	if c.baseContext == nil || (ctx != context.Background() && ctx != context.TODO()) {
+		return ctx
+	}
+
+	if baseCtx := c.baseContext(); baseCtx != nil {
+		return baseCtx
+	}
+
+	return ctx
+}
+
+func (c *gocbcoreQueryClient) audit(statement, clientContextID string, startTime time.Time, dispatched bool, err error) {
+	if c.auditHook == nil {
+		return
+	}
+
+	if globalLogRedactionLevel != RedactNone {
+		statement = redactUserDataString(statement)
+	}
+
+	c.auditHook(AuditRecord{
+		Statement:       statement,
+		User:            c.username,
+		Timestamp:       startTime,
+		ClientContextID: clientContextID,
+		Dispatched:      dispatched,
+		Err:             err,
+	})
+}
+
+// marshalParameter encodes the parameter using the marshaler, the result is embedded within the request payload
+// as is and so must be valid JSON.
+func marshalParameter(marshaler ParameterMarshaler, value interface{}) (json.RawMessage, error) {
+	data, err := marshaler.Marshal(value)
+	if err != nil {
+		return nil, err // nolint: wrapcheck
+	}
+
+	if !json.Valid(data) {
+		return nil, errors.New("marshaler did not produce valid JSON") // nolint: err113
+	}
+
+	return data, nil
+}
+
 func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, statement string, opts *QueryOptions) (*gocbcore.ColumnarQueryOptions, error) {
 	var priority *int
 
@@ -76,11 +339,41 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 
 	execOpts := make(map[string]interface{})
 	if opts.PositionalParameters != nil {
-		execOpts["args"] = opts.PositionalParameters
+		args := opts.PositionalParameters
+
+		if opts.ParameterMarshaler != nil {
+			args = make([]interface{}, len(opts.PositionalParameters))
+
+			for i, value := range opts.PositionalParameters {
+				marshaled, err := marshalParameter(opts.ParameterMarshaler, value)
+				if err != nil {
+					return nil, invalidArgumentError{
+						ArgumentName: "PositionalParameters",
+						Reason:       fmt.Sprintf("failed to marshal parameter %d: %s", i, err),
+					}
+				}
+
+				args[i] = marshaled
+			}
+		}
+
+		execOpts["args"] = args
 	}
 
 	if opts.NamedParameters != nil {
 		for key, value := range opts.NamedParameters {
+			if opts.ParameterMarshaler != nil {
+				marshaled, err := marshalParameter(opts.ParameterMarshaler, value)
+				if err != nil {
+					return nil, invalidArgumentError{
+						ArgumentName: "NamedParameters",
+						Reason:       fmt.Sprintf("failed to marshal parameter %s: %s", key, err),
+					}
+				}
+
+				value = marshaled
+			}
+
 			if !strings.HasPrefix(key, "$") {
 				key = "$" + key
 			}
@@ -95,6 +388,20 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 		}
 	}
 
+	for key, value := range opts.Hints {
+		_, reserved := reservedQueryParameters[key]
+		_, collides := execOpts[key]
+
+		if reserved || collides || strings.HasPrefix(key, "$") {
+			return nil, invalidArgumentError{
+				ArgumentName: "Hints",
+				Reason:       fmt.Sprintf("hint %s collides with a query parameter", key),
+			}
+		}
+
+		execOpts[key] = value
+	}
+
 	if opts.ScanConsistency != nil {
 		switch {
 		case *opts.ScanConsistency == QueryScanConsistencyNotBounded:
@@ -113,11 +420,59 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 		execOpts["readonly"] = *opts.ReadOnly
 	}
 
+	timeout := c.defaultQueryTimeout
+
 	deadline, ok := ctx.Deadline()
 	if ok {
-		execOpts["timeout"] = (time.Until(deadline) + 5*time.Second).String()
-	} else {
-		execOpts["timeout"] = c.defaultQueryTimeout.String()
+		timeout = deadline.Sub(c.now()) + 5*time.Second
+	}
+
+	execOpts["timeout"] = c.clampQueryTimeout(timeout).String()
+
+	if opts.AutoRestartOnConnectionLoss != nil && *opts.AutoRestartOnConnectionLoss &&
+		(opts.ReadOnly == nil || !*opts.ReadOnly) {
+		return nil, invalidArgumentError{
+			ArgumentName: "AutoRestartOnConnectionLoss",
+			Reason:       "can only be used with read-only queries",
+		}
+	}
+
+	// A restarted sampled query returns a different subset of rows, so the rows to skip cannot be determined.
+	if opts.AutoRestartOnConnectionLoss != nil && *opts.AutoRestartOnConnectionLoss && opts.Sample != nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "AutoRestartOnConnectionLoss",
+			Reason:       "cannot be used with Sample",
+		}
+	}
+
+	if opts.ConnectTimeout != nil && *opts.ConnectTimeout <= 0 {
+		return nil, invalidArgumentError{
+			ArgumentName: "ConnectTimeout",
+			Reason:       "must be greater than 0",
+		}
+	}
+
+	if opts.MaxRowBytes != nil && *opts.MaxRowBytes < 0 {
+		return nil, invalidArgumentError{
+			ArgumentName: "MaxRowBytes",
+			Reason:       "must not be negative",
+		}
+	}
+
+	if opts.Sample != nil {
+		if *opts.Sample <= 0 || *opts.Sample > 1 {
+			return nil, invalidArgumentError{
+				ArgumentName: "Sample",
+				Reason:       "must be greater than 0 and at most 1",
+			}
+		}
+
+		sampled, err := sampleStatement(statement, *opts.Sample)
+		if err != nil {
+			return nil, err
+		}
+
+		statement = sampled
 	}
 
 	execOpts["statement"] = statement
@@ -130,24 +485,199 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 	}, nil
 }
 
+// reservedQueryParameters are the request parameters which are set by the SDK, and so cannot be used as hints.
+var reservedQueryParameters = map[string]struct{}{
+	"args":              {},
+	"client_context_id": {},
+	"query_context":     {},
+	"readonly":          {},
+	"scan_consistency":  {},
+	"statement":         {},
+	"timeout":           {},
+}
+
+var collectionReferenceRegexp = regexp.MustCompile(
+	"(?i)\\b(?:FROM|JOIN)\\s+(`(?:[^`]|``)+`|[A-Za-z_][A-Za-z0-9_$]*)\\s*([.(]?)")
+
+// unqualifiedCollectionReference returns the first collection referenced in a FROM or JOIN clause of the
+// statement which is not qualified with a database and scope. References followed by a dot are qualified, and those
+// followed by an opening parenthesis are function calls, such as RANGE.
+func unqualifiedCollectionReference(statement string) (string, bool) {
+	// Fingerprinting removes literals and comments, so that their content cannot be mistaken for a reference.
+	for _, match := range collectionReferenceRegexp.FindAllStringSubmatch(FingerprintStatement(statement), -1) {
+		if match[2] == "" {
+			return match[1], true
+		}
+	}
+
+	return "", false
+}
+
+func sampleStatement(statement string, sample float64) (string, error) {
+	statement, err := subqueryStatement(statement)
+	if err != nil {
+		return "", err
+	}
+
+	// The subquery is followed by a newline so that it cannot be affected by a trailing line comment.
+	return fmt.Sprintf("SELECT VALUE sampled FROM (\n%s\n) AS sampled WHERE RANDOM() < %s",
+		statement, strconv.FormatFloat(sample, 'f', -1, 64)), nil
+}
+
+// translateQueryError translates an error returned when executing a query, returning whether the query may have
+// been dispatched to the server. A recorded TLS handshake failure is only reported if no response was received.
+func (c *gocbcoreQueryClient) translateQueryError(ctx context.Context, statement string, err error,
+	handshakeErrs *tlsHandshakeRecorder) (bool, error) {
+	var coreErr *gocbcore.ColumnarError
+
+	dispatched := !errors.As(err, &coreErr) || !coreErr.WasNotDispatched
+
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, ErrConnectTimeout):
+		return false, newConnectTimeoutError(statement, err)
+	case errors.Is(cause, ErrQueryCanceled):
+		return dispatched, newClientSideError(err, "query was canceled", ErrQueryCanceled)
+	case handshakeErrs.Err() != nil && (coreErr == nil || (coreErr.HTTPResponseCode == 0 && len(coreErr.Errors) == 0)):
+		// gocbcore may have retried against an endpoint which did respond, in which case the response is reported.
+		return false, newClientSideError(err, handshakeErrs.Err().Error(), ErrTLSHandshake)
+	default:
+		return dispatched, translateGocbcoreError(err, c.maxErrorDescriptors)
+	}
+}
+
+// newQueryClientTrace creates the trace used to observe the connections made whilst dispatching a query.
+func newQueryClientTrace(endpoints *endpointRecorder, handshakeErrs *tlsHandshakeRecorder) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: endpoints.record,
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				handshakeErrs.record(err)
+			}
+		},
+	}
+}
+
+// tlsHandshakeRecorder records the most recent TLS handshake failure observed whilst dispatching a query.
+// gocbcore retries requests which fail to send, so without this the handshake error would be replaced by
+// a timeout.
+type tlsHandshakeRecorder struct {
+	lock sync.Mutex
+	err  error
+}
+
+func (r *tlsHandshakeRecorder) record(err error) {
+	r.lock.Lock()
+	r.err = err
+	r.lock.Unlock()
+}
+
+func (r *tlsHandshakeRecorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
+// endpointRecorder records each endpoint which a connection is requested for whilst dispatching a query, including
+// those of any requests which gocbcore retries.
+type endpointRecorder struct {
+	lock      sync.Mutex
+	endpoints []string
+}
+
+func (r *endpointRecorder) record(hostPort string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, endpoint := range r.endpoints {
+		if endpoint == hostPort {
+			return
+		}
+	}
+
+	r.endpoints = append(r.endpoints, hostPort)
+}
+
+func (r *endpointRecorder) Endpoints() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]string(nil), r.endpoints...)
+}
+
+// columnarRowReader is the subset of gocbcore.ColumnarRowReader used by gocbcoreRowReader.
+type columnarRowReader interface {
+	NextRow() []byte
+	Err() error
+	MetaData() ([]byte, error)
+	Close() error
+}
+
 type gocbcoreRowReader struct {
-	reader *gocbcore.ColumnarRowReader
+	ctx    context.Context
+	reader columnarRowReader
+
+	onDone   func()
+	doneOnce sync.Once
+
+	cancel         context.CancelCauseFunc
+	rowReadTimeout time.Duration
+
+	maxErrorDescriptors int
+	queryContext        string
+	endpoints           *endpointRecorder
 }
 
-func (c *gocbcoreQueryClient) newRowReader(result *gocbcore.ColumnarRowReader) *gocbcoreRowReader {
+func (c *gocbcoreQueryClient) newRowReader(ctx context.Context, result columnarRowReader, onDone func(),
+	cancel context.CancelCauseFunc, rowReadTimeout time.Duration, queryContext string,
+	endpoints *endpointRecorder,
+) *gocbcoreRowReader {
 	return &gocbcoreRowReader{
-		reader: result,
+		ctx:                 ctx,
+		reader:              result,
+		onDone:              onDone,
+		doneOnce:            sync.Once{},
+		cancel:              cancel,
+		rowReadTimeout:      rowReadTimeout,
+		maxErrorDescriptors: c.maxErrorDescriptors,
+		queryContext:        queryContext,
+		endpoints:           endpoints,
 	}
 }
 
+func (c *gocbcoreRowReader) done() {
+	c.doneOnce.Do(c.onDone)
+}
+
 func (c *gocbcoreRowReader) NextRow() []byte {
-	return c.reader.NextRow()
+	if c.rowReadTimeout > 0 {
+		timer := time.AfterFunc(c.rowReadTimeout, func() {
+			c.cancel(ErrRowTimeout)
+		})
+		defer timer.Stop()
+	}
+
+	row := c.reader.NextRow()
+	if row == nil {
+		c.done()
+	}
+
+	return row
+}
+
+func (c *gocbcoreRowReader) RawMetaData() ([]byte, error) {
+	metaBytes, err := c.reader.MetaData()
+	if err != nil {
+		return nil, translateGocbcoreError(err, c.maxErrorDescriptors)
+	}
+
+	return metaBytes, nil
 }
 
 func (c *gocbcoreRowReader) MetaData() (*QueryMetadata, error) {
 	metaBytes, err := c.reader.MetaData()
 	if err != nil {
-		return nil, translateGocbcoreError(err)
+		return nil, translateGocbcoreError(err, c.maxErrorDescriptors)
 	}
 
 	var jsonResp jsonAnalyticsResponse
@@ -166,7 +696,10 @@ func (c *gocbcoreRowReader) MetaData() (*QueryMetadata, error) {
 			ResultSize:       0,
 			ProcessedObjects: 0,
 		},
-		Warnings: nil,
+		Warnings:           nil,
+		ObservedKeys:       nil,
+		QueryContext:       c.queryContext,
+		EndpointsContacted: c.endpoints.Endpoints(),
 	}
 	meta.fromData(jsonResp)
 
@@ -175,8 +708,10 @@ func (c *gocbcoreRowReader) MetaData() (*QueryMetadata, error) {
 
 func (c *gocbcoreRowReader) Close() error {
 	err := c.reader.Close()
+	c.done()
+
 	if err != nil {
-		return translateGocbcoreError(err)
+		return translateGocbcoreError(err, c.maxErrorDescriptors)
 	}
 
 	return nil
@@ -185,18 +720,92 @@ func (c *gocbcoreRowReader) Close() error {
 func (c *gocbcoreRowReader) Err() error {
 	err := c.reader.Err()
 	if err != nil {
-		return translateGocbcoreError(err)
+		switch cause := context.Cause(c.ctx); {
+		case errors.Is(cause, ErrQueryCanceled):
+			return newClientSideError(err, "query was canceled", ErrQueryCanceled)
+		case errors.Is(cause, ErrRowTimeout):
+			return newClientSideError(err, "row was not received within the row read timeout", ErrRowTimeout)
+		}
+
+		return translateGocbcoreError(err, c.maxErrorDescriptors)
 	}
 
 	return nil
 }
 
-func translateGocbcoreError(err error) error {
+func (c *gocbcoreQueryClient) clampQueryTimeout(timeout time.Duration) time.Duration {
+	if c.minQueryTimeout > 0 && timeout < c.minQueryTimeout {
+		logInfof("Query timeout of %s is below the minimum, using %s", timeout, c.minQueryTimeout)
+
+		return c.minQueryTimeout
+	}
+
+	if c.maxQueryTimeout > 0 && timeout > c.maxQueryTimeout {
+		logInfof("Query timeout of %s is above the maximum, using %s", timeout, c.maxQueryTimeout)
+
+		return c.maxQueryTimeout
+	}
+
+	return timeout
+}
+
+func newConnectTimeoutError(statement string, err error) error {
+	var endpoint string
+
+	var coreErr *gocbcore.ColumnarError
+	if errors.As(err, &coreErr) {
+		endpoint = coreErr.Endpoint
+	}
+
+	return withCoreError(newColumnarError(statement, endpoint, 0).
+		withMessage("connection could not be established within the connect timeout").
+		withCause(ErrConnectTimeout), coreErr)
+}
+
+func newClientSideError(err error, message string, cause error) error {
+	var statement, endpoint string
+
+	var coreErr *gocbcore.ColumnarError
+	if errors.As(err, &coreErr) {
+		statement = coreErr.Statement
+		endpoint = coreErr.Endpoint
+	}
+
+	return withCoreError(newColumnarError(statement, endpoint, 0).
+		withMessage(message).
+		withCause(cause), coreErr)
+}
+
+// translateGocbcoreError translates a gocbcore error into an SDK error, retaining at most maxErrorDescriptors of
+// the error descriptors returned by the server. A maxErrorDescriptors of 0 retains every descriptor.
+func translateGocbcoreError(err error, maxErrorDescriptors int) error {
 	var coreErr *gocbcore.ColumnarError
 	if !errors.As(err, &coreErr) {
 		return err
 	}
 
+	translated := withCoreError(translateColumnarError(err, coreErr), coreErr)
+
+	var columnarErr *ColumnarError
+	if maxErrorDescriptors > 0 && errors.As(translated, &columnarErr) {
+		columnarErr.limitErrors(maxErrorDescriptors)
+	}
+
+	return translated
+}
+
+// withCoreError attaches the gocbcore error to the ColumnarError within err, so that it can be retrieved
+// using UnwrapCore.
+func withCoreError(err error, coreErr *gocbcore.ColumnarError) error {
+	var columnarErr *ColumnarError
+	if errors.As(err, &columnarErr) {
+		columnarErr.coreErr = coreErr
+	}
+
+	return err
+}
+
+func translateColumnarError(err error, coreErr *gocbcore.ColumnarError) error {
 	if coreErr.HTTPResponseCode == 401 || errors.Is(err, gocbcore.ErrAuthenticationFailure) {
 		return newColumnarError(coreErr.Statement, coreErr.Endpoint, coreErr.HTTPResponseCode).
 			withMessage(coreErr.InnerError.Error()).
@@ -242,6 +851,12 @@ func translateGocbcoreError(err error) error {
 				withCause(ErrTimeout)
 		}
 
+		if code == 23007 {
+			return newColumnarError(coreErr.Statement, coreErr.Endpoint, coreErr.HTTPResponseCode).
+				withErrors(descs).
+				withCause(ErrServerBusy)
+		}
+
 		qErr := newQueryError(coreErr.Statement, coreErr.Endpoint, coreErr.HTTPResponseCode, code, msg).
 			withErrors(descs)
 
@@ -279,7 +894,8 @@ func translateGocbcoreError(err error) error {
 	case errors.Is(coreErr.InnerError, gocbcore.ErrAuthenticationFailure):
 		baseErr.cause = ErrInvalidCredential
 	default:
-		baseErr.cause = errors.New(err.Error()) // nolint: err113
+		// The inner error is preserved so that callers can inspect it using errors.Is and errors.As.
+		baseErr.cause = coreErr.InnerError
 	}
 
 	return baseErr
@@ -0,0 +1,852 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+func TestSubqueryStatement(t *testing.T) {
+	testCases := map[string]string{
+		"SELECT 1":                                      "SELECT 1",
+		"  select 1;  ":                                 "select 1",
+		"SELECT 1;;":                                    "SELECT 1",
+		"SELECT 1 -- comment":                           "SELECT 1",
+		"SELECT 1; -- comment":                          "SELECT 1",
+		"SELECT 1 /* comment; */ ;":                     "SELECT 1",
+		"SELECT 1 -- comment\nFROM coll;\n":             "SELECT 1 -- comment\nFROM coll",
+		"SELECT ';' AS s, `a;b` AS `c--d`":              "SELECT ';' AS s, `a;b` AS `c--d`",
+		"WITH x AS (SELECT 1) SELECT * FROM x":          "WITH x AS (SELECT 1) SELECT * FROM x",
+		"FROM coll SELECT *":                            "FROM coll SELECT *",
+		"/* leading */ (SELECT 1) UNION ALL (SELECT 2)": "/* leading */ (SELECT 1) UNION ALL (SELECT 2)",
+	}
+
+	for statement, expected := range testCases {
+		actual, err := subqueryStatement(statement)
+		require.NoError(t, err, statement)
+
+		assert.Equal(t, expected, actual, statement)
+	}
+
+	for _, statement := range []string{
+		"",
+		"-- only a comment",
+		"SELECT 1; SELECT 2",
+		"UPSERT INTO coll {\"id\": 1}",
+		"CREATE COLLECTION coll",
+		"EXPLAIN SELECT 1",
+	} {
+		_, err := subqueryStatement(statement)
+		require.ErrorIs(t, err, ErrInvalidArgument, statement)
+	}
+}
+
+func TestTranslateQueryOptionsSample(t *testing.T) {
+	client := newTestQueryClient()
+
+	coreOpts, err := client.translateQueryOptions(context.Background(), "SELECT * FROM coll; ", NewQueryOptions().SetSample(0.25))
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT VALUE sampled FROM (\nSELECT * FROM coll\n) AS sampled WHERE RANDOM() < 0.25",
+		coreOpts.Payload["statement"])
+
+	_, err = client.translateQueryOptions(context.Background(), "UPSERT INTO coll {\"id\": 1}",
+		NewQueryOptions().SetSample(0.25))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().SetSample(0))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().SetSample(1.5))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestTranslateQueryOptionsClampTimeout(t *testing.T) {
+	client := newTestQueryClient()
+	client.minQueryTimeout = 10 * time.Second
+	client.maxQueryTimeout = time.Hour
+
+	t.Run("Default", func(tt *testing.T) {
+		coreOpts, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions())
+		require.NoError(tt, err)
+
+		assert.Equal(tt, (10 * time.Minute).String(), coreOpts.Payload["timeout"])
+	})
+
+	t.Run("BelowMinimum", func(tt *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		coreOpts, err := client.translateQueryOptions(ctx, "SELECT 1", NewQueryOptions())
+		require.NoError(tt, err)
+
+		assert.Equal(tt, (10 * time.Second).String(), coreOpts.Payload["timeout"])
+	})
+
+	t.Run("AboveMaximum", func(tt *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+		defer cancel()
+
+		coreOpts, err := client.translateQueryOptions(ctx, "SELECT 1", NewQueryOptions())
+		require.NoError(tt, err)
+
+		assert.Equal(tt, time.Hour.String(), coreOpts.Payload["timeout"])
+	})
+}
+
+func TestNamespaceQueryContext(t *testing.T) {
+	ns := &gocbcoreQueryClientNamespace{
+		Database: "my`db",
+		Scope:    "scope",
+	}
+
+	assert.Equal(t, "default:`my``db`.`scope`", ns.QueryContext())
+}
+
+func TestProjectRowFields(t *testing.T) {
+	fields := map[string]struct{}{"name": {}, "id": {}}
+
+	projected, err := projectRowFields([]byte(`{"id":1,"address":{"name":"x"},"name":"hotel","tags":[1,2]}`), fields)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"hotel"}`, string(projected))
+
+	projected, err = projectRowFields([]byte(`{"address":"x"}`), fields)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(projected))
+
+	projected, err = projectRowFields([]byte(`[1,2,3]`), fields)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,2,3]`, string(projected))
+
+	_, err = projectRowFields([]byte(`{"id":`), fields)
+	require.ErrorIs(t, err, ErrUnmarshal)
+}
+
+func TestObserveRowKeys(t *testing.T) {
+	keys := make(map[string]struct{})
+
+	observeRowKeys([]byte(`{"id":1,"name":"hotel"}`), keys)
+	observeRowKeys([]byte(`{"id":2,"address":{"city":"x"}}`), keys)
+	observeRowKeys([]byte(`[1,2,3]`), keys)
+	observeRowKeys([]byte(`{"id":`), keys)
+
+	assert.Equal(t, map[string]struct{}{"id": {}, "name": {}, "address": {}}, keys)
+}
+
+func TestTranslateQueryOptionsTimeout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	client := newTestQueryClient()
+	client.now = func() time.Time {
+		return now
+	}
+
+	coreOpts, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, "10m0s", coreOpts.Payload["timeout"])
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(30*time.Second))
+	defer cancel()
+
+	coreOpts, err = client.translateQueryOptions(ctx, "SELECT 1", NewQueryOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, "35s", coreOpts.Payload["timeout"])
+}
+
+type unixTimeMarshaler struct{}
+
+func (m unixTimeMarshaler) Marshal(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return []byte(strconv.FormatInt(v.Unix(), 10)), nil
+	case string:
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+func TestTranslateQueryOptionsParameterMarshaler(t *testing.T) {
+	client := newTestQueryClient()
+	when := time.Unix(1700000000, 0)
+
+	coreOpts, err := client.translateQueryOptions(context.Background(), "SELECT $1, $when", NewQueryOptions().
+		SetPositionalParameters([]interface{}{when, 5}).
+		SetNamedParameters(map[string]interface{}{"when": when}).
+		SetParameterMarshaler(unixTimeMarshaler{}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{json.RawMessage("1700000000"), json.RawMessage("5")}, coreOpts.Payload["args"])
+	assert.Equal(t, json.RawMessage("1700000000"), coreOpts.Payload["$when"])
+
+	// The marshaler writes strings as is, which is not valid JSON.
+	_, err = client.translateQueryOptions(context.Background(), "SELECT $1", NewQueryOptions().
+		SetPositionalParameters([]interface{}{"not json"}).
+		SetParameterMarshaler(unixTimeMarshaler{}))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+
+	coreOpts, err = client.translateQueryOptions(context.Background(), "SELECT $1", NewQueryOptions().
+		SetPositionalParameters([]interface{}{when}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{when}, coreOpts.Payload["args"])
+}
+
+func TestTranslateQueryOptionsHints(t *testing.T) {
+	client := newTestQueryClient()
+
+	coreOpts, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+		SetHints(map[string]interface{}{"compiler.joinmemory": "64MB"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "64MB", coreOpts.Payload["compiler.joinmemory"])
+
+	testCases := map[string]*QueryOptions{
+		"Reserved":       NewQueryOptions().SetHints(map[string]interface{}{"timeout": "1s"}),
+		"NamedParameter": NewQueryOptions().SetHints(map[string]interface{}{"$name": "value"}),
+		"Raw": NewQueryOptions().
+			SetRaw(map[string]interface{}{"compiler.joinmemory": "32MB"}).
+			SetHints(map[string]interface{}{"compiler.joinmemory": "64MB"}),
+	}
+
+	for name, opts := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			_, err := client.translateQueryOptions(context.Background(), "SELECT 1", opts)
+			require.ErrorIs(tt, err, ErrInvalidArgument)
+		})
+	}
+}
+
+func TestUnqualifiedCollectionReference(t *testing.T) {
+	testCases := map[string]string{
+		"SELECT * FROM airline": "airline",
+		"SELECT * FROM `travel-sample`.inventory.airline a JOIN route r": "route",
+		"SELECT * FROM `my``coll` WHERE x = 1":                           "`my``coll`",
+		"FROM RANGE(0, 10) AS i SELECT RAW i":                            "",
+		"SELECT * FROM db.scope.airline WHERE name = 'FROM airline'":     "",
+		"SELECT 1 -- FROM airline":                                       "",
+		"SELECT * FROM [1, 2] AS i":                                      "",
+		"SELECT * FROM (SELECT RAW 1) AS i":                              "",
+	}
+
+	for statement, expected := range testCases {
+		collection, found := unqualifiedCollectionReference(statement)
+
+		assert.Equal(t, expected != "", found, statement)
+		assert.Equal(t, expected, collection, statement)
+	}
+}
+
+func TestQueryDetectMissingQueryContext(t *testing.T) {
+	client := newTestQueryClient()
+
+	_, err := client.Query(context.Background(), "SELECT * FROM airline", NewQueryOptions().SetDetectMissingQueryContext(true))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestTranslateQueryOptionsConnectTimeout(t *testing.T) {
+	client := newTestQueryClient()
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		_, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+			SetConnectTimeout(timeout))
+		require.ErrorIs(t, err, ErrInvalidArgument)
+	}
+
+	_, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+		SetConnectTimeout(time.Second))
+	require.NoError(t, err)
+}
+
+func TestTranslateQueryOptionsAutoRestart(t *testing.T) {
+	client := newTestQueryClient()
+
+	_, err := client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+		SetAutoRestartOnConnectionLoss(true))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+		SetAutoRestartOnConnectionLoss(true).
+		SetReadOnly(true))
+	require.NoError(t, err)
+
+	_, err = client.translateQueryOptions(context.Background(), "SELECT 1", NewQueryOptions().
+		SetAutoRestartOnConnectionLoss(true).
+		SetReadOnly(true).
+		SetSample(0.5))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestQueryWithAdditionalRetriesDeadline(t *testing.T) {
+	agent := &testColumnarAgent{
+		err: &gocbcore.ColumnarError{
+			InnerError:       errors.New("something went wrong"), // nolint: err113
+			Statement:        "select 1",
+			Errors:           []gocbcore.ColumnarErrorDesc{{Code: 23007, Message: "busy", Retry: false}},
+			LastErrorCode:    23007,
+			LastErrorMsg:     "busy",
+			Endpoint:         "endpoint",
+			ErrorText:        "",
+			HTTPResponseCode: 503,
+			WasNotDispatched: false,
+		},
+		calls:    0,
+		timeouts: nil,
+	}
+
+	client := newTestQueryClient()
+	client.agent = agent
+	client.additionalRetriableCodes = map[uint32]struct{}{23007: {}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	// The clock is an hour ahead, so retrying would exceed the deadline.
+	client.now = func() time.Time {
+		return time.Now().Add(time.Hour)
+	}
+
+	_, err := client.queryWithAdditionalRetries(ctx, gocbcore.ColumnarQueryOptions{
+		Payload:      nil,
+		Priority:     nil,
+		User:         "",
+		TraceContext: nil,
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, 1, agent.calls)
+}
+
+func TestQueryWithAdditionalRetriesServerTimeout(t *testing.T) {
+	agent := &testColumnarAgent{
+		err: &gocbcore.ColumnarError{
+			InnerError:       errors.New("something went wrong"), // nolint: err113
+			Statement:        "select 1",
+			Errors:           []gocbcore.ColumnarErrorDesc{{Code: 23007, Message: "busy", Retry: false}},
+			LastErrorCode:    23007,
+			LastErrorMsg:     "busy",
+			Endpoint:         "endpoint",
+			ErrorText:        "",
+			HTTPResponseCode: 503,
+			WasNotDispatched: false,
+		},
+		calls:    0,
+		timeouts: nil,
+	}
+
+	client := newTestQueryClient()
+	client.agent = agent
+	client.additionalRetriableCodes = map[uint32]struct{}{23007: {}}
+
+	// The clock advances by 4 seconds each time it is read, so with a 10 second timeout the query is retried twice
+	// before no time remains.
+	start := time.Now()
+	reads := 0
+	client.now = func() time.Time {
+		now := start.Add(time.Duration(reads) * 4 * time.Second)
+		reads++
+
+		return now
+	}
+
+	payload := map[string]interface{}{
+		"statement": "select 1",
+		"timeout":   "10s",
+	}
+
+	// The context has no deadline, so only the timeout sent to the server bounds the retries.
+	_, err := client.queryWithAdditionalRetries(context.Background(), gocbcore.ColumnarQueryOptions{
+		Payload:      payload,
+		Priority:     nil,
+		User:         "",
+		TraceContext: nil,
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, 3, agent.calls)
+	assert.Equal(t, []interface{}{"10s", "5.9s", "1.8s"}, agent.timeouts)
+	assert.Equal(t, "10s", payload["timeout"])
+}
+
+func TestIsAdditionallyRetriable(t *testing.T) {
+	newErr := func(descs ...gocbcore.ColumnarErrorDesc) error {
+		return &gocbcore.ColumnarError{
+			InnerError:       errors.New("something went wrong"), // nolint: err113
+			Statement:        "select 1",
+			Errors:           descs,
+			LastErrorCode:    0,
+			LastErrorMsg:     "",
+			Endpoint:         "endpoint",
+			ErrorText:        "",
+			HTTPResponseCode: 500,
+			WasNotDispatched: false,
+		}
+	}
+
+	codes := map[uint32]struct{}{25000: {}}
+
+	assert.True(t, isAdditionallyRetriable(newErr(
+		gocbcore.ColumnarErrorDesc{Code: 25000, Message: "internal error", Retry: false},
+		gocbcore.ColumnarErrorDesc{Code: 23007, Message: "job queue is full", Retry: true},
+	), codes))
+	assert.False(t, isAdditionallyRetriable(newErr(
+		gocbcore.ColumnarErrorDesc{Code: 25000, Message: "internal error", Retry: false},
+		gocbcore.ColumnarErrorDesc{Code: 24045, Message: "cannot find dataset", Retry: false},
+	), codes))
+	assert.False(t, isAdditionallyRetriable(newErr(
+		gocbcore.ColumnarErrorDesc{Code: 23007, Message: "job queue is full", Retry: true},
+	), codes))
+	assert.False(t, isAdditionallyRetriable(newErr(
+		gocbcore.ColumnarErrorDesc{Code: 25000, Message: "internal error", Retry: false},
+	), nil))
+	assert.False(t, isAdditionallyRetriable(context.DeadlineExceeded, codes))
+}
+
+func TestEndpointRecorder(t *testing.T) {
+	recorder := &endpointRecorder{
+		lock:      sync.Mutex{},
+		endpoints: nil,
+	}
+
+	assert.Empty(t, recorder.Endpoints())
+
+	recorder.record("node1:18095")
+	recorder.record("node2:18095")
+	recorder.record("node1:18095")
+
+	assert.Equal(t, []string{"node1:18095", "node2:18095"}, recorder.Endpoints())
+}
+
+type warningRowReader struct {
+	rows     []string
+	warnings []QueryWarning
+}
+
+func (r *warningRowReader) NextRow() []byte {
+	if len(r.rows) == 0 {
+		return nil
+	}
+
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+
+	return []byte(row)
+}
+
+func (r *warningRowReader) MetaData() (*QueryMetadata, error) {
+	return &QueryMetadata{
+		RequestID: "",
+		Metrics: QueryMetrics{
+			ElapsedTime:      0,
+			ExecutionTime:    0,
+			ResultCount:      0,
+			ResultSize:       0,
+			ProcessedObjects: 0,
+		},
+		Warnings:           r.warnings,
+		ObservedKeys:       nil,
+		QueryContext:       "",
+		EndpointsContacted: nil,
+	}, nil
+}
+
+func (r *warningRowReader) Close() error {
+	return nil
+}
+
+func (r *warningRowReader) Err() error {
+	return nil
+}
+
+func TestQueryResultExpectNonEmptyRowFilter(t *testing.T) {
+	res := NewQueryResult(&failingRowReader{rows: []string{"1", "2", "3"}, err: nil}, nil)
+	res.rowFilter = func([]byte) bool { return false }
+
+	require.ErrorIs(t, res.ExpectNonEmpty(), ErrNoRows)
+	assert.Nil(t, res.NextRow())
+
+	res = NewQueryResult(&failingRowReader{rows: []string{"1", "2", "3"}, err: nil}, nil)
+	res.rowFilter = func(row []byte) bool { return string(row) != "1" }
+
+	require.NoError(t, res.ExpectNonEmpty())
+
+	values, _, err := BufferQueryResult[int](res)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{2, 3}, values)
+}
+
+func TestQueryResultExpectNonEmptyTee(t *testing.T) {
+	res := NewQueryResult(&failingRowReader{rows: []string{"1", "2"}, err: nil}, nil)
+
+	require.NoError(t, res.ExpectNonEmpty())
+
+	for _, teeRes := range res.Tee(2) {
+		var indexes, values []int
+
+		for row := teeRes.NextRow(); row != nil; row = teeRes.NextRow() {
+			var value int
+			require.NoError(t, row.ContentAs(&value))
+
+			indexes = append(indexes, row.index)
+			values = append(values, value)
+		}
+
+		require.NoError(t, teeRes.Err())
+
+		assert.Equal(t, []int{0, 1}, indexes)
+		assert.Equal(t, []int{1, 2}, values)
+	}
+}
+
+func TestQueryResultTreatWarningsAsErrors(t *testing.T) {
+	warnings := []QueryWarning{{Code: 1001, Message: "implicit type coercion"}}
+
+	res := NewQueryResult(&warningRowReader{rows: []string{"1", "2"}, warnings: warnings}, nil)
+	res.treatWarningsAsErrors = true
+
+	var values []int
+	for row := res.NextRow(); row != nil; row = res.NextRow() {
+		var value int
+		require.NoError(t, row.ContentAs(&value))
+
+		values = append(values, value)
+	}
+
+	assert.Equal(t, []int{1, 2}, values)
+
+	err := res.Err()
+	require.ErrorIs(t, err, ErrQueryWarning)
+
+	var warningErr *WarningError
+	require.ErrorAs(t, err, &warningErr)
+	assert.Equal(t, warnings, warningErr.Warnings())
+
+	res = NewQueryResult(&warningRowReader{rows: []string{"1"}, warnings: nil}, nil)
+	res.treatWarningsAsErrors = true
+
+	_, _, err = BufferQueryResult[int](res)
+	require.NoError(t, err)
+
+	_, _, err = BufferQueryResult[int](NewQueryResult(&warningRowReader{rows: []string{"1"}, warnings: warnings}, nil))
+	require.NoError(t, err)
+}
+
+type countQueryClient struct {
+	statement string
+	opts      *QueryOptions
+}
+
+func (c *countQueryClient) Query(_ context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
+	c.statement = statement
+	c.opts = opts
+
+	// The result applies row processing as if the options had not been cleared, to check that the count is read
+	// from the underlying reader.
+	res := NewQueryResult(&failingRowReader{rows: []string{"42"}, err: nil}, nil)
+	res.rowFilter = func([]byte) bool { return false }
+	res.projectFields = map[string]struct{}{"name": {}}
+
+	return res, nil
+}
+
+func TestExecuteCount(t *testing.T) {
+	client := &countQueryClient{
+		statement: "",
+		opts:      nil,
+	}
+
+	opts := NewQueryOptions().
+		SetReadOnly(true).
+		SetRowFilter(func([]byte) bool { return false }).
+		SetProjectFields([]string{"name"}).
+		SetRowTransform(func(row []byte) ([]byte, error) { return row, nil }).
+		SetMaxRowBytes(1).
+		SetSample(0.5)
+
+	count, err := executeCount(context.Background(), client, "SELECT * FROM coll; -- comment", opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), count)
+	assert.Equal(t, "SELECT VALUE COUNT(*) FROM (\nSELECT * FROM coll\n) AS counted", client.statement)
+
+	require.NotNil(t, client.opts.ReadOnly)
+	assert.True(t, *client.opts.ReadOnly)
+	assert.Nil(t, client.opts.RowFilter)
+	assert.Nil(t, client.opts.ProjectFields)
+	assert.Nil(t, client.opts.RowTransform)
+	assert.Nil(t, client.opts.MaxRowBytes)
+	assert.Nil(t, client.opts.Sample)
+
+	// The options of the caller are left unchanged.
+	assert.NotNil(t, opts.RowFilter)
+
+	_, err = executeCount(context.Background(), client, "UPSERT INTO coll {\"id\": 1}", opts)
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+// stallingColumnarRowReader returns its rows immediately, and then blocks until the context is done.
+type stallingColumnarRowReader struct {
+	ctx  context.Context
+	rows []string
+}
+
+func (r *stallingColumnarRowReader) NextRow() []byte {
+	if len(r.rows) > 0 {
+		row := r.rows[0]
+		r.rows = r.rows[1:]
+
+		return []byte(row)
+	}
+
+	<-r.ctx.Done()
+
+	return nil
+}
+
+func (r *stallingColumnarRowReader) Err() error {
+	return r.ctx.Err()
+}
+
+func (r *stallingColumnarRowReader) MetaData() ([]byte, error) {
+	return nil, r.ctx.Err()
+}
+
+func (r *stallingColumnarRowReader) Close() error {
+	return nil
+}
+
+func TestRowReadTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	reader := newTestQueryClient().newRowReader(ctx, &stallingColumnarRowReader{ctx: ctx, rows: []string{"1"}},
+		func() {}, cancel, 50*time.Millisecond, "", &endpointRecorder{lock: sync.Mutex{}, endpoints: nil})
+
+	require.Equal(t, []byte("1"), reader.NextRow())
+
+	// Time spent processing a row between calls is not subject to the timeout.
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, ctx.Err())
+
+	assert.Nil(t, reader.NextRow())
+
+	err := reader.Err()
+	require.ErrorIs(t, err, ErrRowTimeout)
+
+	var columnarErr *ColumnarError
+	require.ErrorAs(t, err, &columnarErr)
+}
+
+func TestTLSHandshakeError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	// The server logs each failed handshake.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	handshakeErrs := &tlsHandshakeRecorder{
+		lock: sync.Mutex{},
+		err:  nil,
+	}
+
+	endpoints := &endpointRecorder{
+		lock:      sync.Mutex{},
+		endpoints: nil,
+	}
+
+	ctx := httptrace.WithClientTrace(context.Background(), newQueryClientTrace(endpoints, handshakeErrs))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	// The server certificate is not trusted by the default client, so the handshake fails.
+	resp, err := http.DefaultClient.Do(req)
+	if resp != nil {
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.Error(t, err)
+	require.Error(t, handshakeErrs.Err())
+
+	assert.Equal(t, []string{srv.Listener.Addr().String()}, endpoints.Endpoints())
+
+	coreErr := &gocbcore.ColumnarError{
+		InnerError:       gocbcore.ErrTimeout,
+		Statement:        "SELECT 1",
+		Errors:           nil,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         srv.Listener.Addr().String(),
+		ErrorText:        "",
+		HTTPResponseCode: 0,
+		WasNotDispatched: false,
+	}
+
+	dispatched, err := newTestQueryClient().translateQueryError(context.Background(), "SELECT 1", coreErr, handshakeErrs)
+	require.ErrorIs(t, err, ErrTLSHandshake)
+
+	assert.False(t, dispatched)
+	assert.Contains(t, err.Error(), handshakeErrs.Err().Error())
+
+	var columnarErr *ColumnarError
+	require.ErrorAs(t, err, &columnarErr)
+
+	assert.Equal(t, srv.Listener.Addr().String(), columnarErr.endpoint)
+
+	// If gocbcore retries against another endpoint which responds then the response is reported instead.
+	coreErr = &gocbcore.ColumnarError{
+		InnerError:       gocbcore.ErrColumnar,
+		Statement:        "SELECT 1",
+		Errors:           []gocbcore.ColumnarErrorDesc{{Code: 24000, Message: "Syntax error", Retry: false}},
+		LastErrorCode:    24000,
+		LastErrorMsg:     "Syntax error",
+		Endpoint:         "other:18095",
+		ErrorText:        "",
+		HTTPResponseCode: 400,
+		WasNotDispatched: false,
+	}
+
+	dispatched, err = newTestQueryClient().translateQueryError(context.Background(), "SELECT 1", coreErr, handshakeErrs)
+	require.NotErrorIs(t, err, ErrTLSHandshake)
+
+	assert.True(t, dispatched)
+
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+
+	assert.Equal(t, 24000, queryErr.Code())
+}
+
+func TestParentContext(t *testing.T) {
+	type ctxKey struct{}
+
+	baseCtx := context.WithValue(context.Background(), ctxKey{}, "base")
+
+	client := newTestQueryClient()
+	client.baseContext = func() context.Context {
+		return baseCtx
+	}
+
+	assert.Equal(t, baseCtx, client.parentContext(context.Background()))
+	assert.Equal(t, baseCtx, client.parentContext(context.TODO()))
+
+	derivedCtx := context.WithValue(context.Background(), ctxKey{}, "derived")
+	assert.Equal(t, derivedCtx, client.parentContext(derivedCtx))
+
+	client.baseContext = func() context.Context {
+		return nil
+	}
+
+	assert.Equal(t, context.Background(), client.parentContext(context.Background()))
+
+	client.baseContext = nil
+
+	assert.Equal(t, context.TODO(), client.parentContext(context.TODO()))
+}
+
+type testColumnarAgent struct {
+	err      error
+	calls    int
+	timeouts []interface{}
+}
+
+func (a *testColumnarAgent) Query(_ context.Context,
+	opts gocbcore.ColumnarQueryOptions) (*gocbcore.ColumnarRowReader, error) {
+	a.calls++
+	a.timeouts = append(a.timeouts, opts.Payload["timeout"])
+
+	return nil, a.err
+}
+
+func TestAuditHook(t *testing.T) {
+	var records []AuditRecord
+
+	agent := &testColumnarAgent{
+		err:      nil,
+		calls:    0,
+		timeouts: nil,
+	}
+
+	client := newTestQueryClient()
+	client.agent = agent
+	client.auditHook = func(record AuditRecord) {
+		records = append(records, record)
+	}
+
+	before := time.Now()
+
+	_, err := client.Query(context.Background(), "SELECT 1", NewQueryOptions())
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, "SELECT 1", records[0].Statement)
+	assert.Equal(t, "username", records[0].User)
+	assert.NotEmpty(t, records[0].ClientContextID)
+	assert.False(t, records[0].Timestamp.Before(before))
+	assert.True(t, records[0].Dispatched)
+	require.NoError(t, records[0].Err)
+
+	agent.err = &gocbcore.ColumnarError{
+		InnerError:       gocbcore.ErrTimeout,
+		Statement:        "SELECT 2",
+		Errors:           nil,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         "",
+		ErrorText:        "",
+		HTTPResponseCode: 0,
+		WasNotDispatched: true,
+	}
+
+	_, err = client.Query(context.Background(), "SELECT 2", NewQueryOptions())
+	require.Error(t, err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "SELECT 2", records[1].Statement)
+	assert.NotEqual(t, records[0].ClientContextID, records[1].ClientContextID)
+	assert.False(t, records[1].Dispatched)
+	assert.Equal(t, err, records[1].Err)
+
+	// Invalid options are rejected before the query is executed, so are not audited.
+	_, err = client.Query(context.Background(), "SELECT 3", NewQueryOptions().SetSample(2))
+	require.ErrorIs(t, err, ErrInvalidArgument)
+
+	assert.Len(t, records, 2)
+}
+
+func newTestQueryClient() *gocbcoreQueryClient {
+	return newGocbcoreQueryClient(nil, gocbcoreQueryClientConfig{
+		defaultQueryTimeout:      10 * time.Minute,
+		minQueryTimeout:          0,
+		maxQueryTimeout:          0,
+		defaultOperationTimeout:  0,
+		slowQueryThreshold:       0,
+		maxErrorDescriptors:      0,
+		additionalRetriableCodes: nil,
+		defaultUnmarshaler:       NewJSONUnmarshaler(),
+		baseContext:              nil,
+		auditHook:                nil,
+		username:                 "username",
+		defaultNamespace:         nil,
+		inFlight:                 nil,
+		closed:                   nil,
+		now:                      time.Now,
+	}, nil)
+}
@@ -1,8 +1,6 @@
 package cbcolumnar
 
 import (
-	"time"
-
 	"github.com/couchbase/gocbcore/v10"
 )
 
@@ -12,21 +10,19 @@ type scopeClient interface {
 }
 
 type gocbcoreScopeClient struct {
-	agent                     *gocbcore.ColumnarAgent
-	name                      string
-	databaseName              string
-	defaultServerQueryTimeout time.Duration
-	defaultUnmarshaler        Unmarshaler
+	agent        *gocbcore.ColumnarAgent
+	name         string
+	databaseName string
+	queryConfig  gocbcoreQueryClientConfig
 }
 
 func newGocbcoreScopeClient(agent *gocbcore.ColumnarAgent, name, databaseName string,
-	defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreScopeClient {
+	queryConfig gocbcoreQueryClientConfig) *gocbcoreScopeClient {
 	return &gocbcoreScopeClient{
-		agent:                     agent,
-		name:                      name,
-		databaseName:              databaseName,
-		defaultServerQueryTimeout: defaultServerQueryTimeout,
-		defaultUnmarshaler:        defaultUnmarshaler,
+		agent:        agent,
+		name:         name,
+		databaseName: databaseName,
+		queryConfig:  queryConfig,
 	}
 }
 
@@ -35,7 +31,7 @@ func (c *gocbcoreScopeClient) Name() string {
 }
 
 func (c *gocbcoreScopeClient) QueryClient() queryClient {
-	return newGocbcoreQueryClient(c.agent, c.defaultServerQueryTimeout, c.defaultUnmarshaler,
+	return newGocbcoreQueryClient(c.agent, c.queryConfig,
 		&gocbcoreQueryClientNamespace{
 			Database: c.databaseName,
 			Scope:    c.name,
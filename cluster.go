@@ -1,13 +1,19 @@
 package cbcolumnar
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"math/rand"
 	"net"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/couchbase/gocbcore/v10"
 	"github.com/couchbaselabs/gocbconnstr"
 )
 
@@ -15,8 +21,30 @@ import (
 // It is used to perform operations on the data against a Couchbase Columnar cluster.
 type Cluster struct {
 	client clusterClient
+
+	defaultQueryOptions *QueryOptions
+	connectionConfig    ConnectionConfig
+}
+
+// ConnectionConfig describes the connection configuration resolved by NewCluster.
+type ConnectionConfig struct {
+	// Addresses are the addresses which the SDK will use to connect to the cluster.
+	Addresses []ResolvedAddress
 }
 
+// ResolvedAddress is a single address resolved by NewCluster.
+type ResolvedAddress struct {
+	Host string
+	Port int
+
+	// FromSRV indicates whether the address was resolved from a DNS SRV record, rather than being taken
+	// directly from the connection string.
+	FromSRV bool
+}
+
+// defaultPort is the port used for addresses in the connection string which do not specify one.
+const defaultPort = 11207
+
 // NewCluster creates a new Cluster instance.
 func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions) (*Cluster, error) {
 	connSpec, err := gocbconnstr.Parse(connStr)
@@ -31,15 +59,195 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		}
 	}
 
+	// Only password authentication is supported, so a Credential without a username and password, for example
+	// one constructed directly rather than with NewCredential, can never authenticate.
+	if credential.UsernamePassword == nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "credential",
+			Reason:       "only username and password credentials are supported, use NewCredential",
+		}
+	}
+
 	clusterOpts := mergeClusterOptions(opts...)
 
 	if clusterOpts == nil {
 		clusterOpts = NewClusterOptions()
 	}
 
+	cfg, err := newClusterConfig(connSpec, clusterOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	useSrv := cfg.useSrv
+
+	var slowQueryThreshold time.Duration
+	if clusterOpts.SlowQueryThreshold != nil {
+		slowQueryThreshold = *clusterOpts.SlowQueryThreshold
+	}
+
+	var addrs []address
+
+	srvRecord := connSpec.SrvRecordName()
+
+	if srvRecord == "" {
+		// SRV is only used when the connection string contains a single hostname without a port, if the user
+		// explicitly asked for SRV then silently using the address list could hide a misconfiguration.
+		if cfg.srvExplicit {
+			logWarnf("srv=true was specified but SRV lookup requires a single hostname without a port, " +
+				"using the connection string addresses instead")
+		}
+
+		useSrv = false
+	}
+
+	if useSrv {
+		lookup := clusterOpts.SRVLookup
+		if lookup == nil {
+			resolver := clusterOpts.Resolver
+			if resolver == nil {
+				resolver = net.DefaultResolver
+			}
+
+			lookup = resolver.LookupSRV
+		}
+
+		_, srvAddrs, err := lookup(context.Background(), cfg.srvService, cfg.srvProto, connSpec.Addresses[0].Host)
+		if err != nil {
+			if isLogRedactionLevelFull() {
+				logInfof("Failed to lookup SRV record: %s", redactSystemData(err))
+			} else {
+				logInfof("Failed to lookup SRV record: %s", err)
+			}
+		}
+
+		for _, srvAddrs := range srvAddrs {
+			addrs = append(addrs, address{
+				Host:    strings.TrimSuffix(srvAddrs.Target, "."),
+				Port:    int(srvAddrs.Port),
+				FromSRV: true,
+			})
+		}
+
+		if len(addrs) == 0 {
+			host := connSpec.Addresses[0].Host
+			if isLogRedactionLevelFull() {
+				host = redactSystemDataString(host)
+			}
+
+			logWarnf("SRV lookup for %s returned no targets, falling back to the connection string address", host)
+
+			useSrv = false
+		}
+	}
+
+	if !useSrv {
+		for _, addr := range connSpec.Addresses {
+			port := addr.Port
+			if port == -1 {
+				port = defaultPort
+			}
+
+			addrs = append(addrs, address{
+				Host:    addr.Host,
+				Port:    port,
+				FromSRV: false,
+			})
+		}
+	}
+
+	orderAddresses(addrs, cfg.addressOrder, clusterOpts.AddressShuffleSeed)
+
+	connectionConfig := ConnectionConfig{
+		Addresses: make([]ResolvedAddress, len(addrs)),
+	}
+
+	for i, addr := range addrs {
+		connectionConfig.Addresses[i] = ResolvedAddress(addr)
+
+		host := addr.Host
+		if isLogRedactionLevelFull() {
+			host = redactSystemDataString(host)
+		}
+
+		logDebugf("Resolved address %s:%d (from SRV: %t)", host, addr.Port, addr.FromSRV)
+	}
+
+	unmarshaler := clusterOpts.Unmarshaler
+	if unmarshaler == nil {
+		unmarshaler = NewJSONUnmarshaler()
+	}
+
+	if cfg.securityOpts.DisableServerCertificateVerification != nil && *cfg.securityOpts.DisableServerCertificateVerification {
+		if cfg.securityOpts.AcknowledgeInsecure != nil && *cfg.securityOpts.AcknowledgeInsecure {
+			logDebugf("insecure certificate verification has been acknowledged, not verifying server certificates")
+		} else {
+			logWarnf("server certificate verification is disabled, this is insecure")
+		}
+	}
+
+	mgr, err := newClusterClient(clusterClientOptions{
+		Spec:                     connSpec,
+		Credential:               &credential,
+		ConnectTimeout:           cfg.connectTimeout,
+		ServerQueryTimeout:       cfg.queryTimeout,
+		MinQueryTimeout:          cfg.minQueryTimeout,
+		MaxQueryTimeout:          cfg.maxQueryTimeout,
+		DefaultOperationTimeout:  cfg.defaultOperationTimeout,
+		SlowQueryThreshold:       slowQueryThreshold,
+		MaxErrorDescriptors:      cfg.maxErrorDescriptors,
+		MaxConnsPerEndpoint:      cfg.maxConnsPerEndpoint,
+		AdditionalRetriableCodes: cfg.additionalRetriableCodes,
+		TLSRootCAProvider:        cfg.tlsRootCAProvider,
+		CipherSuites:             cfg.cipherSuites,
+		DisableSrv:               !useSrv,
+		SRVService:               cfg.srvService,
+		SRVProto:                 cfg.srvProto,
+		Addresses:                addrs,
+		Unmarshaler:              unmarshaler,
+		BaseContext:              clusterOpts.BaseContext,
+		AuditHook:                clusterOpts.AuditHook,
+		DefaultNamespace:         clusterOpts.DefaultNamespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		client:              mgr,
+		defaultQueryOptions: nil,
+		connectionConfig:    connectionConfig,
+	}
+
+	return c, nil
+}
+
+// clusterConfig contains the cluster configuration resolved from the connection string and ClusterOptions.
+type clusterConfig struct {
+	connectTimeout           time.Duration
+	queryTimeout             time.Duration
+	minQueryTimeout          time.Duration
+	maxQueryTimeout          time.Duration
+	defaultOperationTimeout  time.Duration
+	maxErrorDescriptors      int
+	maxConnsPerEndpoint      int
+	additionalRetriableCodes map[uint32]struct{}
+	addressOrder             AddressOrder
+	useSrv                   bool
+	srvExplicit              bool
+	srvService               string
+	srvProto                 string
+	securityOpts             *SecurityOptions
+	cipherSuites             []*tls.CipherSuite
+	tlsRootCAProvider        func() *x509.CertPool
+}
+
+// newClusterConfig resolves and validates the cluster configuration, it performs no network I/O.
+func newClusterConfig(connSpec gocbconnstr.ConnSpec, clusterOpts *ClusterOptions) (*clusterConfig, error) {
 	connectTimeout := 10000 * time.Millisecond
 	queryTimeout := 10 * time.Minute
 	useSrv := true
+	srvExplicit := false
 
 	timeoutOpts := clusterOpts.TimeoutOptions
 	if timeoutOpts == nil {
@@ -51,6 +259,33 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		securityOpts = NewSecurityOptions()
 	}
 
+	srvService := "couchbases"
+	srvProto := "tcp"
+
+	if srvOpts := clusterOpts.SRVOptions; srvOpts != nil {
+		if srvOpts.Service != "" {
+			srvService = srvOpts.Service
+		}
+
+		if srvOpts.Proto != "" {
+			srvProto = srvOpts.Proto
+		}
+	}
+
+	if !isValidSRVService(srvService) {
+		return nil, invalidArgumentError{
+			ArgumentName: "SRVOptions.Service",
+			Reason:       "must contain only letters, digits and hyphens, and must not begin or end with a hyphen",
+		}
+	}
+
+	if srvProto != "tcp" && srvProto != "udp" {
+		return nil, invalidArgumentError{
+			ArgumentName: "SRVOptions.Proto",
+			Reason:       "must be one of tcp or udp",
+		}
+	}
+
 	if timeoutOpts.ConnectTimeout != nil {
 		connectTimeout = *timeoutOpts.ConnectTimeout
 	}
@@ -59,6 +294,74 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		queryTimeout = *timeoutOpts.QueryTimeout
 	}
 
+	var minQueryTimeout, maxQueryTimeout time.Duration
+
+	if timeoutOpts.MinQueryTimeout != nil {
+		minQueryTimeout = *timeoutOpts.MinQueryTimeout
+	}
+
+	if timeoutOpts.MaxQueryTimeout != nil {
+		maxQueryTimeout = *timeoutOpts.MaxQueryTimeout
+	}
+
+	var defaultOperationTimeout time.Duration
+	if timeoutOpts.DefaultOperationTimeout != nil {
+		defaultOperationTimeout = *timeoutOpts.DefaultOperationTimeout
+	}
+
+	maxErrorDescriptors := 16
+	if clusterOpts.MaxErrorDescriptors != nil {
+		if *clusterOpts.MaxErrorDescriptors < 1 {
+			return nil, invalidArgumentError{
+				ArgumentName: "MaxErrorDescriptors",
+				Reason:       "must be at least 1",
+			}
+		}
+
+		maxErrorDescriptors = *clusterOpts.MaxErrorDescriptors
+	}
+
+	var maxConnsPerEndpoint int
+	if clusterOpts.MaxConnectionsPerEndpoint != nil {
+		if *clusterOpts.MaxConnectionsPerEndpoint < 0 {
+			return nil, invalidArgumentError{
+				ArgumentName: "MaxConnectionsPerEndpoint",
+				Reason:       "must not be negative",
+			}
+		}
+
+		maxConnsPerEndpoint = *clusterOpts.MaxConnectionsPerEndpoint
+	}
+
+	var additionalRetriableCodes map[uint32]struct{}
+	if len(clusterOpts.AdditionalRetriableCodes) > 0 {
+		additionalRetriableCodes = make(map[uint32]struct{}, len(clusterOpts.AdditionalRetriableCodes))
+
+		for _, code := range clusterOpts.AdditionalRetriableCodes {
+			if code < 0 {
+				return nil, invalidArgumentError{
+					ArgumentName: "AdditionalRetriableCodes",
+					Reason:       "codes must not be negative",
+				}
+			}
+
+			additionalRetriableCodes[uint32(code)] = struct{}{}
+		}
+	}
+
+	addressOrder := AddressOrderAsIs
+	if clusterOpts.AddressOrder != nil {
+		switch *clusterOpts.AddressOrder {
+		case AddressOrderAsIs, AddressOrderRandom, AddressOrderSorted:
+			addressOrder = *clusterOpts.AddressOrder
+		default:
+			return nil, invalidArgumentError{
+				ArgumentName: "AddressOrder",
+				Reason:       "unrecognized address order",
+			}
+		}
+	}
+
 	fetchOption := func(name string) (string, bool) {
 		optValue := connSpec.Options[name]
 		if len(optValue) == 0 {
@@ -78,6 +381,17 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		}
 
 		useSrv = val
+		srvExplicit = val
+	}
+
+	for _, addr := range connSpec.Addresses {
+		// A port of -1 indicates that the port was omitted, in which case defaultPort is used.
+		if addr.Port != -1 && (addr.Port < 1 || addr.Port > 65535) {
+			return nil, invalidArgumentError{
+				ArgumentName: "port",
+				Reason:       fmt.Sprintf("port %d of %s must be between 1 and 65535", addr.Port, addr.Host),
+			}
+		}
 	}
 
 	if valStr, ok := fetchOption("timeout.connect_timeout"); ok {
@@ -161,83 +475,230 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		cipherSuites[i] = s
 	}
 
-	if connectTimeout == 0 {
+	tlsRootCAProvider, err := newTLSRootCAProvider(securityOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if connectTimeout <= 0 {
 		return nil, invalidArgumentError{
 			ArgumentName: "ConnectTimeout",
 			Reason:       "must be greater than 0",
 		}
 	}
 
-	if queryTimeout == 0 {
+	if queryTimeout <= 0 {
 		return nil, invalidArgumentError{
 			ArgumentName: "QueryTimeout",
 			Reason:       "must be greater than 0",
 		}
 	}
 
-	var addrs []address
+	if minQueryTimeout < 0 {
+		return nil, invalidArgumentError{
+			ArgumentName: "MinQueryTimeout",
+			Reason:       "must not be negative",
+		}
+	}
 
-	srvRecord := connSpec.SrvRecordName()
+	if maxQueryTimeout < 0 || (maxQueryTimeout > 0 && maxQueryTimeout < minQueryTimeout) {
+		return nil, invalidArgumentError{
+			ArgumentName: "MaxQueryTimeout",
+			Reason:       "must not be negative or less than MinQueryTimeout",
+		}
+	}
 
-	if srvRecord == "" {
-		useSrv = false
+	if defaultOperationTimeout < 0 {
+		return nil, invalidArgumentError{
+			ArgumentName: "DefaultOperationTimeout",
+			Reason:       "must not be negative",
+		}
 	}
 
-	if useSrv {
-		_, srvAddrs, err := net.LookupSRV("couchbases", "tcp", connSpec.Addresses[0].Host)
+	if ns := clusterOpts.DefaultNamespace; ns != nil && (ns.Database == "" || ns.Scope == "") {
+		return nil, invalidArgumentError{
+			ArgumentName: "DefaultNamespace",
+			Reason:       "database and scope must both be specified",
+		}
+	}
+
+	return &clusterConfig{
+		connectTimeout:           connectTimeout,
+		queryTimeout:             queryTimeout,
+		minQueryTimeout:          minQueryTimeout,
+		maxQueryTimeout:          maxQueryTimeout,
+		defaultOperationTimeout:  defaultOperationTimeout,
+		maxErrorDescriptors:      maxErrorDescriptors,
+		maxConnsPerEndpoint:      maxConnsPerEndpoint,
+		additionalRetriableCodes: additionalRetriableCodes,
+		addressOrder:             addressOrder,
+		useSrv:                   useSrv,
+		srvExplicit:              srvExplicit,
+		srvService:               srvService,
+		srvProto:                 srvProto,
+		securityOpts:             securityOpts,
+		cipherSuites:             cipherSuites,
+		tlsRootCAProvider:        tlsRootCAProvider,
+	}, nil
+}
+
+// newTLSRootCAProvider creates the provider of the root certificates which are trusted when connecting, reading any
+// trusted certificates from the sources specified by the SecurityOptions.
+func newTLSRootCAProvider(securityOpts *SecurityOptions) (func() *x509.CertPool, error) {
+	trustOnly := securityOpts.TrustOnly
+	if trustOnly == nil {
+		trustOnly = TrustOnlyCapella{}
+	}
+
+	var caProvider func() *x509.CertPool
+
+	switch to := trustOnly.(type) {
+	case TrustOnlyCapella:
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(capellaRootCA)
+
+		caProvider = func() *x509.CertPool {
+			return pool
+		}
+	case TrustOnlySystem:
+		pool, err := x509.SystemCertPool()
 		if err != nil {
-			if isLogRedactionLevelFull() {
-				logInfof("Failed to lookup SRV record: %s", redactSystemData(err))
-			} else {
-				logInfof("Failed to lookup SRV record: %s", err)
-			}
+			return nil, fmt.Errorf("failed to read system cert pool %w", err)
 		}
 
-		for _, srvAddrs := range srvAddrs {
-			addrs = append(addrs, address{
-				Host: strings.TrimSuffix(srvAddrs.Target, "."),
-				Port: int(srvAddrs.Port),
-			})
+		caProvider = func() *x509.CertPool {
+			return pool
 		}
-	} else {
-		for _, addr := range connSpec.Addresses {
-			addrs = append(addrs, address{
-				Host: addr.Host,
-				Port: addr.Port,
-			})
+	case TrustOnlyPemFile:
+		data, err := os.ReadFile(to.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pem file %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(data)
+
+		caProvider = func() *x509.CertPool {
+			return pool
+		}
+	case TrustOnlyPemString:
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(to.Pem))
+
+		caProvider = func() *x509.CertPool {
+			return pool
+		}
+	case TrustOnlyCertificates:
+		caProvider = func() *x509.CertPool {
+			return to.Certificates
 		}
 	}
 
-	unmarshaler := clusterOpts.Unmarshaler
-	if unmarshaler == nil {
-		unmarshaler = NewJSONUnmarshaler()
+	if securityOpts.DisableServerCertificateVerification != nil && *securityOpts.DisableServerCertificateVerification {
+		caProvider = func() *x509.CertPool {
+			return nil
+		}
+	}
+
+	return caProvider, nil
+}
+
+// orderAddresses reorders the resolved addresses in place according to the address order.
+func orderAddresses(addrs []address, order AddressOrder, seed *int64) {
+	switch order {
+	case AddressOrderRandom:
+		var source rand.Source
+		if seed != nil {
+			source = rand.NewSource(*seed)
+		} else {
+			source = rand.NewSource(time.Now().UnixNano())
+		}
+
+		rand.New(source).Shuffle(len(addrs), func(i, j int) {
+			addrs[i], addrs[j] = addrs[j], addrs[i]
+		})
+	case AddressOrderSorted:
+		sort.SliceStable(addrs, func(i, j int) bool {
+			if addrs[i].Host != addrs[j].Host {
+				return addrs[i].Host < addrs[j].Host
+			}
+
+			return addrs[i].Port < addrs[j].Port
+		})
+	case AddressOrderAsIs:
 	}
+}
 
-	if clusterOpts.SecurityOptions.DisableServerCertificateVerification != nil && *clusterOpts.SecurityOptions.DisableServerCertificateVerification {
-		logWarnf("server certificate verification is disabled, this is insecure")
+// ValidateClusterOptions validates the provided ClusterOptions using the same validation as NewCluster, without
+// connecting to the cluster. Options which can be specified within the connection string are not validated.
+// The sources of trusted certificates, such as the file of a TrustOnlyPemFile, are read as part of validation.
+func ValidateClusterOptions(opts *ClusterOptions) error {
+	_, err := newClusterConfig(gocbconnstr.ConnSpec{
+		Scheme:    "couchbases",
+		Addresses: nil,
+		Bucket:    "",
+		Options:   nil,
+	}, mergeClusterOptions(opts))
+
+	return err
+}
+
+func isValidSRVService(service string) bool {
+	if service == "" || strings.HasPrefix(service, "-") || strings.HasSuffix(service, "-") {
+		return false
 	}
 
-	mgr, err := newClusterClient(clusterClientOptions{
-		Spec:                                 connSpec,
-		Credential:                           &credential,
-		ConnectTimeout:                       connectTimeout,
-		ServerQueryTimeout:                   queryTimeout,
-		TrustOnly:                            securityOpts.TrustOnly,
-		DisableServerCertificateVerification: securityOpts.DisableServerCertificateVerification,
-		CipherSuites:                         cipherSuites,
-		DisableSrv:                           !useSrv,
-		Addresses:                            addrs,
-		Unmarshaler:                          unmarshaler,
-	})
-	if err != nil {
-		return nil, err
+	for _, r := range service {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
 	}
 
-	c := &Cluster{
-		client: mgr,
+	return true
+}
+
+// WithDefaults returns a new Cluster which shares the underlying connections of this Cluster, but applies the
+// provided QueryOptions as defaults to every query executed against it, or against any Database or Scope created
+// from it. Options provided when executing a query take precedence over these defaults.
+// Closing either Cluster closes the shared connections, and so affects both.
+func (c *Cluster) WithDefaults(opts *QueryOptions) *Cluster {
+	return &Cluster{
+		client:              c.client,
+		defaultQueryOptions: mergeQueryOptions(c.defaultQueryOptions, opts),
+		connectionConfig:    c.connectionConfig,
 	}
+}
 
-	return c, nil
+// ConnectionConfig returns the connection configuration which was resolved when the Cluster was created,
+// including the addresses that the SDK connects to and whether each was resolved using DNS SRV.
+func (c *Cluster) ConnectionConfig() ConnectionConfig {
+	addresses := make([]ResolvedAddress, len(c.connectionConfig.Addresses))
+	copy(addresses, c.connectionConfig.Addresses)
+
+	return ConnectionConfig{
+		Addresses: addresses,
+	}
+}
+
+// CancelByTag cancels all in-flight queries with the given tag, as set using QueryOptions.Tags, returning the
+// number of queries canceled. Canceled queries return an error wrapping ErrQueryCanceled.
+// Queries are considered in-flight until all of their rows have been read or the result has been closed.
+func (c *Cluster) CancelByTag(key, value string) int {
+	return c.client.CancelByTag(key, value)
+}
+
+// InternalAgent returns the underlying gocbcore agent used by this Cluster, providing access to capabilities
+// which have not yet been exposed by this SDK.
+// Returns nil if the Cluster is not backed by a gocbcore agent.
+//
+// Internal: This is not covered by any compatibility guarantees and may change or be removed at any time.
+func (c *Cluster) InternalAgent() *gocbcore.ColumnarAgent {
+	client, ok := c.client.(*gocbcoreClusterClient)
+	if !ok {
+		return nil
+	}
+
+	return client.agent
 }
 
 // Close shuts down the cluster and releases all resources.
@@ -1,7 +1,10 @@
 package cbcolumnar
 
 import (
+	"context"
+	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"time"
 )
 
@@ -42,6 +45,19 @@ type TrustOnlySystem struct{}
 
 func (t TrustOnlySystem) trustOnly() {}
 
+// AddressOrder specifies the order in which the resolved addresses are used when bootstrapping.
+type AddressOrder uint
+
+const (
+	// AddressOrderAsIs indicates that addresses are used in the order in which they were resolved, either the order
+	// of the connection string or of the SRV lookup.
+	AddressOrderAsIs AddressOrder = iota + 1
+	// AddressOrderRandom indicates that addresses are shuffled, distributing bootstrap load across nodes.
+	AddressOrderRandom
+	// AddressOrderSorted indicates that addresses are sorted by host and then port.
+	AddressOrderSorted
+)
+
 // SecurityOptions specifies options for controlling security related
 // items such as TLS root certificates and verification skipping.
 type SecurityOptions struct {
@@ -52,6 +68,10 @@ type SecurityOptions struct {
 	// regardless of validity.
 	DisableServerCertificateVerification *bool
 
+	// AcknowledgeInsecure when specified acknowledges that DisableServerCertificateVerification is insecure,
+	// suppressing the warning that is otherwise logged when creating a Cluster. Verification remains disabled.
+	AcknowledgeInsecure *bool
+
 	// CipherSuites specifies the TLS cipher suites the SDK is allowed to use when negotiating TLS
 	// settings, or an empty list to use any cipher suite supported by the runtime environment.
 	// See: https://go.dev/src/crypto/tls/cipher_suites.go
@@ -63,6 +83,7 @@ func NewSecurityOptions() *SecurityOptions {
 	return &SecurityOptions{
 		TrustOnly:                            TrustOnlyCapella{},
 		DisableServerCertificateVerification: nil,
+		AcknowledgeInsecure:                  nil,
 		CipherSuites:                         nil,
 	}
 }
@@ -81,6 +102,13 @@ func (opts *SecurityOptions) SetDisableServerCertificateVerification(disabled bo
 	return opts
 }
 
+// SetAcknowledgeInsecure sets the AcknowledgeInsecure field in SecurityOptions.
+func (opts *SecurityOptions) SetAcknowledgeInsecure(acknowledged bool) *SecurityOptions {
+	opts.AcknowledgeInsecure = &acknowledged
+
+	return opts
+}
+
 // SetCipherSuites sets the CipherSuites field in SecurityOptions.
 func (opts *SecurityOptions) SetCipherSuites(cipherSuites []string) *SecurityOptions {
 	opts.CipherSuites = cipherSuites
@@ -88,6 +116,37 @@ func (opts *SecurityOptions) SetCipherSuites(cipherSuites []string) *SecurityOpt
 	return opts
 }
 
+// CipherSuite describes a TLS cipher suite which can be specified within SecurityOptions.CipherSuites.
+type CipherSuite struct {
+	// Name is the name of the cipher suite, as accepted by SecurityOptions.CipherSuites.
+	Name string
+
+	// Insecure indicates that the cipher suite has security issues, using it is not recommended.
+	Insecure bool
+}
+
+// SupportedCipherSuites returns the cipher suites which can be specified within SecurityOptions.CipherSuites, as
+// implemented by the runtime environment. Secure cipher suites are listed first.
+func SupportedCipherSuites() []CipherSuite {
+	var suites []CipherSuite
+
+	for _, suite := range tls.CipherSuites() {
+		suites = append(suites, CipherSuite{
+			Name:     suite.Name,
+			Insecure: false,
+		})
+	}
+
+	for _, suite := range tls.InsecureCipherSuites() {
+		suites = append(suites, CipherSuite{
+			Name:     suite.Name,
+			Insecure: true,
+		})
+	}
+
+	return suites
+}
+
 // TimeoutOptions specifies options for various operation timeouts.
 type TimeoutOptions struct {
 	// ConnectTimeout specifies the socket connection timeout, or more broadly the timeout
@@ -99,13 +158,29 @@ type TimeoutOptions struct {
 	// This value is only used if the context.Context at the operation level does not specify a deadline.
 	// Default = 10 minutes
 	QueryTimeout *time.Duration
+
+	// MinQueryTimeout specifies the minimum timeout which will be sent to the server for any query.
+	// Any timeout derived from the context.Context deadline, or from QueryTimeout, below this value is raised to it.
+	MinQueryTimeout *time.Duration
+
+	// MaxQueryTimeout specifies the maximum timeout which will be sent to the server for any query.
+	// Any timeout derived from the context.Context deadline, or from QueryTimeout, above this value is lowered to it.
+	MaxQueryTimeout *time.Duration
+
+	// DefaultOperationTimeout specifies a timeout which is applied as a deadline to the context.Context of any
+	// query executed with a context.Context which has no deadline. Unlike QueryTimeout, which is only sent to the
+	// server, this also bounds the time spent waiting on the client.
+	DefaultOperationTimeout *time.Duration
 }
 
 // NewTimeoutOptions creates a new instance of TimeoutOptions.
 func NewTimeoutOptions() *TimeoutOptions {
 	return &TimeoutOptions{
-		ConnectTimeout: nil,
-		QueryTimeout:   nil,
+		ConnectTimeout:          nil,
+		QueryTimeout:            nil,
+		MinQueryTimeout:         nil,
+		MaxQueryTimeout:         nil,
+		DefaultOperationTimeout: nil,
 	}
 }
 
@@ -123,6 +198,78 @@ func (opts *TimeoutOptions) SetQueryTimeout(timeout time.Duration) *TimeoutOptio
 	return opts
 }
 
+// SetMinQueryTimeout sets the MinQueryTimeout field in TimeoutOptions.
+func (opts *TimeoutOptions) SetMinQueryTimeout(timeout time.Duration) *TimeoutOptions {
+	opts.MinQueryTimeout = &timeout
+
+	return opts
+}
+
+// SetMaxQueryTimeout sets the MaxQueryTimeout field in TimeoutOptions.
+func (opts *TimeoutOptions) SetMaxQueryTimeout(timeout time.Duration) *TimeoutOptions {
+	opts.MaxQueryTimeout = &timeout
+
+	return opts
+}
+
+// SetDefaultOperationTimeout sets the DefaultOperationTimeout field in TimeoutOptions.
+func (opts *TimeoutOptions) SetDefaultOperationTimeout(timeout time.Duration) *TimeoutOptions {
+	opts.DefaultOperationTimeout = &timeout
+
+	return opts
+}
+
+// SRVLookupFunc looks up the SRV records for the given service, protocol and name, with the same semantics as
+// net.Resolver.LookupSRV.
+type SRVLookupFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+// SRVOptions specifies options for controlling the DNS SRV lookup used to discover the cluster addresses.
+type SRVOptions struct {
+	// Service specifies the service name of the SRV record to look up.
+	// Default = "couchbases"
+	Service string
+
+	// Proto specifies the protocol of the SRV record to look up, either "tcp" or "udp".
+	// Default = "tcp"
+	Proto string
+}
+
+// NewSRVOptions creates a new instance of SRVOptions.
+func NewSRVOptions() *SRVOptions {
+	return &SRVOptions{
+		Service: "",
+		Proto:   "",
+	}
+}
+
+// SetService sets the Service field in SRVOptions.
+func (opts *SRVOptions) SetService(service string) *SRVOptions {
+	opts.Service = service
+
+	return opts
+}
+
+// SetProto sets the Proto field in SRVOptions.
+func (opts *SRVOptions) SetProto(proto string) *SRVOptions {
+	opts.Proto = proto
+
+	return opts
+}
+
+// NamespaceOptions specifies a database and scope to use as the namespace for queries.
+type NamespaceOptions struct {
+	Database string
+	Scope    string
+}
+
+// NewNamespaceOptions creates a new instance of NamespaceOptions.
+func NewNamespaceOptions(database, scope string) *NamespaceOptions {
+	return &NamespaceOptions{
+		Database: database,
+		Scope:    scope,
+	}
+}
+
 // ClusterOptions specifies options for configuring the cluster.
 type ClusterOptions struct {
 	// TimeoutOptions specifies various operation timeouts.
@@ -131,23 +278,100 @@ type ClusterOptions struct {
 	// SecurityOptions specifies security related configuration options.
 	SecurityOptions *SecurityOptions
 
+	// SRVOptions specifies DNS SRV related configuration options.
+	SRVOptions *SRVOptions
+
 	// Unmarshaler specifies the default unmarshaler to use for decoding query response rows.
+	// This is only used for queries where neither QueryOptions.Unmarshaler is set nor the context carries an
+	// Unmarshaler, see ContextWithUnmarshaler.
 	Unmarshaler Unmarshaler
+
+	// BaseContext specifies a function returning the context.Context to use as the parent for queries
+	// when the context.Context provided at the operation level is context.Background, context.TODO (or nil).
+	// Any other context, including contexts derived from context.Background, is always used as provided.
+	BaseContext func() context.Context
+
+	// DefaultNamespace specifies the database and scope used as the query context for queries executed at
+	// the Cluster level. Queries executed against a Scope, or which set query_context via QueryOptions.Raw,
+	// are unaffected.
+	DefaultNamespace *NamespaceOptions
+
+	// AuditHook specifies a function which is invoked once for every query executed, recording
+	// details about the query suitable for audit logging. It is invoked once the query has either failed or
+	// started returning results.
+	AuditHook func(AuditRecord)
+
+	// Resolver specifies the DNS resolver used to look up SRV records when connecting.
+	// Defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+
+	// SlowQueryThreshold specifies a duration above which queries are logged at warn level, along with their
+	// client context ID. The duration is measured from when the query is sent until all rows have been read, or the
+	// query fails. Statements are redacted according to the log redaction level. Disabled by default.
+	SlowQueryThreshold *time.Duration
+
+	// MaxErrorDescriptors specifies the maximum number of error descriptors returned by the server which are retained
+	// within an error, any further descriptors are omitted and only counted. The first non-retriable descriptor is
+	// always retained. Defaults to 16.
+	MaxErrorDescriptors *int
+
+	// MaxConnectionsPerEndpoint specifies the maximum number of connections which are opened to any single endpoint,
+	// including those which are idle. Queries which would exceed the limit wait for a connection to become available,
+	// which counts towards their timeout. Defaults to 0, meaning no limit.
+	MaxConnectionsPerEndpoint *int
+
+	// AdditionalRetriableCodes specifies server error codes which should be retried, in addition to the errors which
+	// the server marks as retriable. Queries failing with only these codes and retriable errors are retried until the
+	// context deadline or the query timeout is reached. Note that retrying a query which is not idempotent, such as one
+	// which modifies data, may cause it to be applied more than once.
+	AdditionalRetriableCodes []int
+
+	// AddressOrder specifies the order in which the resolved addresses are used when bootstrapping.
+	// Default = AddressOrderAsIs
+	AddressOrder *AddressOrder
+
+	// AddressShuffleSeed specifies the seed used to shuffle the addresses when AddressOrder is AddressOrderRandom,
+	// making the order deterministic. This is intended for use within tests.
+	// Default = a random seed
+	AddressShuffleSeed *int64
+
+	// SRVLookup specifies a function used to look up SRV records when connecting, taking precedence over
+	// Resolver. This is primarily useful for testing.
+	SRVLookup SRVLookupFunc
 }
 
 // NewClusterOptions creates a new instance of ClusterOptions.
 func NewClusterOptions() *ClusterOptions {
 	return &ClusterOptions{
 		TimeoutOptions: &TimeoutOptions{
-			ConnectTimeout: nil,
-			QueryTimeout:   nil,
+			ConnectTimeout:          nil,
+			QueryTimeout:            nil,
+			MinQueryTimeout:         nil,
+			MaxQueryTimeout:         nil,
+			DefaultOperationTimeout: nil,
 		},
 		SecurityOptions: &SecurityOptions{
 			TrustOnly:                            TrustOnlyCapella{},
 			DisableServerCertificateVerification: nil,
+			AcknowledgeInsecure:                  nil,
 			CipherSuites:                         nil,
 		},
-		Unmarshaler: nil,
+		SRVOptions: &SRVOptions{
+			Service: "",
+			Proto:   "",
+		},
+		Unmarshaler:               nil,
+		BaseContext:               nil,
+		DefaultNamespace:          nil,
+		AuditHook:                 nil,
+		Resolver:                  nil,
+		SlowQueryThreshold:        nil,
+		MaxErrorDescriptors:       nil,
+		MaxConnectionsPerEndpoint: nil,
+		AdditionalRetriableCodes:  nil,
+		AddressOrder:              nil,
+		AddressShuffleSeed:        nil,
+		SRVLookup:                 nil,
 	}
 }
 
@@ -165,6 +389,13 @@ func (co *ClusterOptions) SetSecurityOptions(securityOptions *SecurityOptions) *
 	return co
 }
 
+// SetSRVOptions sets the SRVOptions field in ClusterOptions.
+func (co *ClusterOptions) SetSRVOptions(srvOptions *SRVOptions) *ClusterOptions {
+	co.SRVOptions = srvOptions
+
+	return co
+}
+
 // SetUnmarshaler sets the Unmarshaler field in ClusterOptions.
 func (co *ClusterOptions) SetUnmarshaler(unmarshaler Unmarshaler) *ClusterOptions {
 	co.Unmarshaler = unmarshaler
@@ -172,11 +403,100 @@ func (co *ClusterOptions) SetUnmarshaler(unmarshaler Unmarshaler) *ClusterOption
 	return co
 }
 
+// SetBaseContext sets the BaseContext field in ClusterOptions.
+func (co *ClusterOptions) SetBaseContext(baseContext func() context.Context) *ClusterOptions {
+	co.BaseContext = baseContext
+
+	return co
+}
+
+// SetDefaultNamespace sets the DefaultNamespace field in ClusterOptions.
+func (co *ClusterOptions) SetDefaultNamespace(namespace *NamespaceOptions) *ClusterOptions {
+	co.DefaultNamespace = namespace
+
+	return co
+}
+
+// SetAuditHook sets the AuditHook field in ClusterOptions.
+func (co *ClusterOptions) SetAuditHook(auditHook func(AuditRecord)) *ClusterOptions {
+	co.AuditHook = auditHook
+
+	return co
+}
+
+// SetResolver sets the Resolver field in ClusterOptions.
+func (co *ClusterOptions) SetResolver(resolver *net.Resolver) *ClusterOptions {
+	co.Resolver = resolver
+
+	return co
+}
+
+// SetSlowQueryThreshold sets the SlowQueryThreshold field in ClusterOptions.
+func (co *ClusterOptions) SetSlowQueryThreshold(threshold time.Duration) *ClusterOptions {
+	co.SlowQueryThreshold = &threshold
+
+	return co
+}
+
+// SetMaxErrorDescriptors sets the MaxErrorDescriptors field in ClusterOptions.
+func (co *ClusterOptions) SetMaxErrorDescriptors(maxDescriptors int) *ClusterOptions {
+	co.MaxErrorDescriptors = &maxDescriptors
+
+	return co
+}
+
+// SetMaxConnectionsPerEndpoint sets the MaxConnectionsPerEndpoint field in ClusterOptions.
+func (co *ClusterOptions) SetMaxConnectionsPerEndpoint(maxConnections int) *ClusterOptions {
+	co.MaxConnectionsPerEndpoint = &maxConnections
+
+	return co
+}
+
+// SetAdditionalRetriableCodes sets the AdditionalRetriableCodes field in ClusterOptions.
+func (co *ClusterOptions) SetAdditionalRetriableCodes(codes []int) *ClusterOptions {
+	co.AdditionalRetriableCodes = codes
+
+	return co
+}
+
+// SetAddressOrder sets the AddressOrder field in ClusterOptions.
+func (co *ClusterOptions) SetAddressOrder(order AddressOrder) *ClusterOptions {
+	co.AddressOrder = &order
+
+	return co
+}
+
+// SetAddressShuffleSeed sets the AddressShuffleSeed field in ClusterOptions.
+func (co *ClusterOptions) SetAddressShuffleSeed(seed int64) *ClusterOptions {
+	co.AddressShuffleSeed = &seed
+
+	return co
+}
+
+// SetSRVLookup sets the SRVLookup field in ClusterOptions.
+func (co *ClusterOptions) SetSRVLookup(lookup SRVLookupFunc) *ClusterOptions {
+	co.SRVLookup = lookup
+
+	return co
+}
+
 func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 	clusterOpts := &ClusterOptions{
-		TimeoutOptions:  nil,
-		SecurityOptions: nil,
-		Unmarshaler:     nil,
+		TimeoutOptions:            nil,
+		SecurityOptions:           nil,
+		SRVOptions:                nil,
+		Unmarshaler:               nil,
+		BaseContext:               nil,
+		DefaultNamespace:          nil,
+		AuditHook:                 nil,
+		Resolver:                  nil,
+		SlowQueryThreshold:        nil,
+		MaxErrorDescriptors:       nil,
+		MaxConnectionsPerEndpoint: nil,
+		AdditionalRetriableCodes:  nil,
+		AddressOrder:              nil,
+		AddressShuffleSeed:        nil,
+		SRVLookup:                 nil,
 	}
 
 	for _, opt := range opts {
@@ -187,8 +507,11 @@ func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 		if opt.TimeoutOptions != nil {
 			if clusterOpts.TimeoutOptions == nil {
 				clusterOpts.TimeoutOptions = &TimeoutOptions{
-					ConnectTimeout: nil,
-					QueryTimeout:   nil,
+					ConnectTimeout:          nil,
+					QueryTimeout:            nil,
+					MinQueryTimeout:         nil,
+					MaxQueryTimeout:         nil,
+					DefaultOperationTimeout: nil,
 				}
 			}
 
@@ -199,6 +522,18 @@ func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 			if opt.TimeoutOptions.QueryTimeout != nil {
 				clusterOpts.TimeoutOptions.QueryTimeout = opt.TimeoutOptions.QueryTimeout
 			}
+
+			if opt.TimeoutOptions.MinQueryTimeout != nil {
+				clusterOpts.TimeoutOptions.MinQueryTimeout = opt.TimeoutOptions.MinQueryTimeout
+			}
+
+			if opt.TimeoutOptions.MaxQueryTimeout != nil {
+				clusterOpts.TimeoutOptions.MaxQueryTimeout = opt.TimeoutOptions.MaxQueryTimeout
+			}
+
+			if opt.TimeoutOptions.DefaultOperationTimeout != nil {
+				clusterOpts.TimeoutOptions.DefaultOperationTimeout = opt.TimeoutOptions.DefaultOperationTimeout
+			}
 		}
 
 		if opt.SecurityOptions != nil {
@@ -206,6 +541,7 @@ func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 				clusterOpts.SecurityOptions = &SecurityOptions{
 					TrustOnly:                            nil,
 					DisableServerCertificateVerification: nil,
+					AcknowledgeInsecure:                  nil,
 					CipherSuites:                         nil,
 				}
 			}
@@ -218,14 +554,79 @@ func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 				clusterOpts.SecurityOptions.DisableServerCertificateVerification = opt.SecurityOptions.DisableServerCertificateVerification
 			}
 
+			if opt.SecurityOptions.AcknowledgeInsecure != nil {
+				clusterOpts.SecurityOptions.AcknowledgeInsecure = opt.SecurityOptions.AcknowledgeInsecure
+			}
+
 			if len(opt.SecurityOptions.CipherSuites) > 0 {
 				clusterOpts.SecurityOptions.CipherSuites = opt.SecurityOptions.CipherSuites
 			}
 		}
 
+		if opt.SRVOptions != nil {
+			if clusterOpts.SRVOptions == nil {
+				clusterOpts.SRVOptions = &SRVOptions{
+					Service: "",
+					Proto:   "",
+				}
+			}
+
+			if opt.SRVOptions.Service != "" {
+				clusterOpts.SRVOptions.Service = opt.SRVOptions.Service
+			}
+
+			if opt.SRVOptions.Proto != "" {
+				clusterOpts.SRVOptions.Proto = opt.SRVOptions.Proto
+			}
+		}
+
 		if opt.Unmarshaler != nil {
 			clusterOpts.Unmarshaler = opt.Unmarshaler
 		}
+
+		if opt.BaseContext != nil {
+			clusterOpts.BaseContext = opt.BaseContext
+		}
+
+		if opt.DefaultNamespace != nil {
+			clusterOpts.DefaultNamespace = opt.DefaultNamespace
+		}
+
+		if opt.AuditHook != nil {
+			clusterOpts.AuditHook = opt.AuditHook
+		}
+
+		if opt.Resolver != nil {
+			clusterOpts.Resolver = opt.Resolver
+		}
+
+		if opt.SlowQueryThreshold != nil {
+			clusterOpts.SlowQueryThreshold = opt.SlowQueryThreshold
+		}
+
+		if opt.MaxErrorDescriptors != nil {
+			clusterOpts.MaxErrorDescriptors = opt.MaxErrorDescriptors
+		}
+
+		if opt.MaxConnectionsPerEndpoint != nil {
+			clusterOpts.MaxConnectionsPerEndpoint = opt.MaxConnectionsPerEndpoint
+		}
+
+		if len(opt.AdditionalRetriableCodes) > 0 {
+			clusterOpts.AdditionalRetriableCodes = opt.AdditionalRetriableCodes
+		}
+
+		if opt.AddressOrder != nil {
+			clusterOpts.AddressOrder = opt.AddressOrder
+		}
+
+		if opt.AddressShuffleSeed != nil {
+			clusterOpts.AddressShuffleSeed = opt.AddressShuffleSeed
+		}
+
+		if opt.SRVLookup != nil {
+			clusterOpts.SRVLookup = opt.SRVLookup
+		}
 	}
 
 	return clusterOpts
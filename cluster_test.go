@@ -1,10 +1,20 @@
 package cbcolumnar_test
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	cbcolumnar "github.com/couchbase/gocbcolumnar"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInvalidCipherSuites(t *testing.T) {
@@ -25,3 +35,326 @@ func TestNoScheme(t *testing.T) {
 
 	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
 }
+
+func TestInvalidCredential(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.Credential{UsernamePassword: nil}, DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidSRVOptions(t *testing.T) {
+	t.Run("Service", func(tt *testing.T) {
+		opts := DefaultOptions().SetSRVOptions(cbcolumnar.NewSRVOptions().SetService("_couchbases"))
+		_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"), opts)
+
+		assert.ErrorIs(tt, err, cbcolumnar.ErrInvalidArgument)
+	})
+
+	t.Run("Proto", func(tt *testing.T) {
+		opts := DefaultOptions().SetSRVOptions(cbcolumnar.NewSRVOptions().SetProto("http"))
+		_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"), opts)
+
+		assert.ErrorIs(tt, err, cbcolumnar.ErrInvalidArgument)
+	})
+}
+
+func TestInvalidDefaultNamespace(t *testing.T) {
+	opts := DefaultOptions().SetDefaultNamespace(cbcolumnar.NewNamespaceOptions("database", ""))
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"), opts)
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestCustomResolver(t *testing.T) {
+	// The resolver fails all lookups so the SDK falls back to the bootstrap host, which logs a warning.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	var called atomic.Bool
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			called.Store(true)
+
+			return nil, errors.New("dial disabled") // nolint: err113
+		},
+	}
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://somenonsense", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetResolver(resolver))
+	require.NoError(t, err)
+
+	assert.True(t, called.Load())
+
+	require.NoError(t, cluster.Close())
+}
+
+// cannedSRVLookup returns an SRVLookupFunc which always returns records, without performing any DNS lookups.
+func cannedSRVLookup(records ...*net.SRV) cbcolumnar.SRVLookupFunc {
+	return func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", records, nil
+	}
+}
+
+func TestSRVLookup(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster("couchbases://somenonsense", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetSRVLookup(cannedSRVLookup(
+			&net.SRV{Target: "node1.example.com.", Port: 11207, Priority: 0, Weight: 0},
+			&net.SRV{Target: "node2.example.com", Port: 11208, Priority: 0, Weight: 0},
+		)))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, cluster.Close())
+	}()
+
+	assert.Equal(t, []cbcolumnar.ResolvedAddress{
+		{Host: "node1.example.com", Port: 11207, FromSRV: true},
+		{Host: "node2.example.com", Port: 11208, FromSRV: true},
+	}, cluster.ConnectionConfig().Addresses)
+}
+
+func TestSRVLookupNoRecords(t *testing.T) {
+	// Falling back to the connection string address logs a warning.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://somenonsense", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetSRVLookup(cannedSRVLookup()))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, cluster.Close())
+	}()
+
+	assert.Equal(t, []cbcolumnar.ResolvedAddress{
+		{Host: "somenonsense", Port: 11207, FromSRV: false},
+	}, cluster.ConnectionConfig().Addresses)
+}
+
+func TestInvalidQueryTimeoutRange(t *testing.T) {
+	opts := DefaultOptions().SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().
+		SetMinQueryTimeout(time.Minute).
+		SetMaxQueryTimeout(time.Second))
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"), opts)
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidPort(t *testing.T) {
+	for _, connStr := range []string{"couchbases://localhost:0", "couchbases://localhost:11207,localhost:70000"} {
+		_, err := cbcolumnar.NewCluster(connStr, cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+
+		assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument, connStr)
+	}
+}
+
+func TestConnectionConfig(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster("couchbases://host1,host2:12000?srv=false", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, []cbcolumnar.ResolvedAddress{
+		{Host: "host1", Port: 11207, FromSRV: false},
+		{Host: "host2", Port: 12000, FromSRV: false},
+	}, cluster.ConnectionConfig().Addresses)
+
+	require.NoError(t, cluster.Close())
+}
+
+func TestAddressOrder(t *testing.T) {
+	connectionConfig := func(opts *cbcolumnar.ClusterOptions) []cbcolumnar.ResolvedAddress {
+		cluster, err := cbcolumnar.NewCluster("couchbases://host3,host1:12000,host2,host1?srv=false",
+			cbcolumnar.NewCredential("username", "password"), opts)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, cluster.Close())
+		}()
+
+		return cluster.ConnectionConfig().Addresses
+	}
+
+	asIs := []cbcolumnar.ResolvedAddress{
+		{Host: "host3", Port: 11207, FromSRV: false},
+		{Host: "host1", Port: 12000, FromSRV: false},
+		{Host: "host2", Port: 11207, FromSRV: false},
+		{Host: "host1", Port: 11207, FromSRV: false},
+	}
+
+	assert.Equal(t, asIs, connectionConfig(DefaultOptions()))
+	assert.Equal(t, asIs, connectionConfig(DefaultOptions().SetAddressOrder(cbcolumnar.AddressOrderAsIs)))
+
+	assert.Equal(t, []cbcolumnar.ResolvedAddress{
+		{Host: "host1", Port: 11207, FromSRV: false},
+		{Host: "host1", Port: 12000, FromSRV: false},
+		{Host: "host2", Port: 11207, FromSRV: false},
+		{Host: "host3", Port: 11207, FromSRV: false},
+	}, connectionConfig(DefaultOptions().SetAddressOrder(cbcolumnar.AddressOrderSorted)))
+
+	shuffled := connectionConfig(DefaultOptions().SetAddressOrder(cbcolumnar.AddressOrderRandom).SetAddressShuffleSeed(42))
+	assert.ElementsMatch(t, asIs, shuffled)
+	assert.Equal(t, shuffled,
+		connectionConfig(DefaultOptions().SetAddressOrder(cbcolumnar.AddressOrderRandom).SetAddressShuffleSeed(42)))
+
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetAddressOrder(cbcolumnar.AddressOrder(10)))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestExplicitSRVNotEligible(t *testing.T) {
+	// SRV cannot be used with multiple addresses, which logs a warning.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://host1,host2?srv=true", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, []cbcolumnar.ResolvedAddress{
+		{Host: "host1", Port: 11207, FromSRV: false},
+		{Host: "host2", Port: 11207, FromSRV: false},
+	}, cluster.ConnectionConfig().Addresses)
+
+	require.NoError(t, cluster.Close())
+}
+
+func TestNamespaceFromContext(t *testing.T) {
+	_, ok := cbcolumnar.NamespaceFromContext(context.Background())
+	assert.False(t, ok)
+
+	namespace := cbcolumnar.NewNamespaceOptions("database", "scope")
+
+	actual, ok := cbcolumnar.NamespaceFromContext(cbcolumnar.ContextWithNamespace(context.Background(), namespace))
+	require.True(t, ok)
+	assert.Same(t, namespace, actual)
+}
+
+func TestInvalidMaxErrorDescriptors(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetMaxErrorDescriptors(0))
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidMaxConnectionsPerEndpoint(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetMaxConnectionsPerEndpoint(-1))
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidAdditionalRetriableCodes(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetAdditionalRetriableCodes([]int{25000, -1}))
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestQueryAfterClose(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster("couchbases://localhost?srv=false", cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+	require.NoError(t, err)
+
+	scope := cluster.Database("database").Scope("scope")
+
+	require.NoError(t, cluster.Close())
+
+	_, err = cluster.ExecuteQuery(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrClusterClosed)
+	require.ErrorIs(t, err, cbcolumnar.ErrClosed)
+
+	_, err = scope.ExecuteQuery(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrClusterClosed)
+}
+
+func TestEncodedTrustOnlyPemFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs with spaces")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+
+	path := filepath.Join(dir, "ca cert.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+
+	connStr := func(path string) string {
+		return "couchbases://localhost?srv=false&security.trust_only_pem_file=" +
+			strings.ReplaceAll(url.QueryEscape(path), "+", "%20")
+	}
+
+	cluster, err := cbcolumnar.NewCluster(connStr(path), cbcolumnar.NewCredential("username", "password"),
+		cbcolumnar.NewClusterOptions())
+	require.NoError(t, err)
+	require.NoError(t, cluster.Close())
+
+	_, err = cbcolumnar.NewCluster(connStr(filepath.Join(dir, "missing cert.pem")),
+		cbcolumnar.NewCredential("username", "password"), cbcolumnar.NewClusterOptions())
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAcknowledgeInsecure(t *testing.T) {
+	opts := cbcolumnar.NewClusterOptions().SetSecurityOptions(cbcolumnar.NewSecurityOptions().
+		SetDisableServerCertificateVerification(true).
+		SetAcknowledgeInsecure(true))
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://localhost?srv=false", cbcolumnar.NewCredential("username", "password"), opts)
+	require.NoError(t, err)
+
+	require.NoError(t, cluster.Close())
+}
+
+func TestSupportedCipherSuites(t *testing.T) {
+	suites := cbcolumnar.SupportedCipherSuites()
+	require.NotEmpty(t, suites)
+
+	var secure, insecure *cbcolumnar.CipherSuite
+
+	for i := range suites {
+		if suites[i].Insecure && insecure == nil {
+			insecure = &suites[i]
+		} else if !suites[i].Insecure && secure == nil {
+			secure = &suites[i]
+		}
+	}
+
+	require.NotNil(t, secure)
+	require.NotNil(t, insecure)
+
+	require.NoError(t, cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetSecurityOptions(cbcolumnar.NewSecurityOptions().SetCipherSuites([]string{secure.Name}))))
+
+	// Insecure suites are accepted, but log a warning.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	require.NoError(t, cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetSecurityOptions(cbcolumnar.NewSecurityOptions().SetCipherSuites([]string{insecure.Name}))))
+}
+
+func TestValidateClusterOptions(t *testing.T) {
+	require.NoError(t, cbcolumnar.ValidateClusterOptions(DefaultOptions()))
+	require.NoError(t, cbcolumnar.ValidateClusterOptions(nil))
+
+	err := cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetSecurityOptions(cbcolumnar.NewSecurityOptions().SetCipherSuites([]string{"bad"})))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetConnectTimeout(0)))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetConnectTimeout(-time.Second)))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetQueryTimeout(0)))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetQueryTimeout(-time.Second)))
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = cbcolumnar.ValidateClusterOptions(cbcolumnar.NewClusterOptions().
+		SetSecurityOptions(cbcolumnar.NewSecurityOptions().
+			SetTrustOnly(cbcolumnar.TrustOnlyPemFile{Path: filepath.Join(t.TempDir(), "missing.pem")})))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -3,12 +3,15 @@ package cbcolumnar
 // Database represents a Columnar database and provides access to Scope.
 type Database struct {
 	client databaseClient
+
+	defaultQueryOptions *QueryOptions
 }
 
 // Database creates a new Database instance.
 func (c *Cluster) Database(name string) *Database {
 	return &Database{
-		client: c.client.Database(name),
+		client:              c.client.Database(name),
+		defaultQueryOptions: c.defaultQueryOptions,
 	}
 }
 
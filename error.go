@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/couchbase/gocbcore/v10"
 )
 
 // ErrColumnar is the base error for any Columnar error that is not captured by a more specific error.
@@ -16,6 +19,35 @@ var ErrInvalidCredential = errors.New("invalid credential")
 // This is returned when a server timeout occurs, or an operation fails to be sent within the dispatch timeout.
 var ErrTimeout = errors.New("timeout error")
 
+// ErrConnectTimeout occurs when a connection could not be established within the connect timeout specified for
+// a query.
+var ErrConnectTimeout = errors.New("connect timeout error")
+
+// ErrQueryCanceled occurs when a query is canceled by the SDK on behalf of the user, for example using
+// Cluster.CancelByTag.
+var ErrQueryCanceled = errors.New("query canceled")
+
+// ErrRowTooLarge occurs when a row in a query result exceeds QueryOptions.MaxRowBytes.
+var ErrRowTooLarge = errors.New("row too large")
+
+// ErrRowTimeout occurs when a row is not received from the stream within QueryOptions.RowReadTimeout.
+var ErrRowTimeout = errors.New("row read timeout")
+
+// ErrTLSHandshake occurs when a TLS handshake with a query endpoint fails, for example due to an untrusted
+// certificate or a protocol mismatch.
+var ErrTLSHandshake = errors.New("tls handshake failed")
+
+// ErrServerBusy occurs when the server rejects a query because its job queue is full.
+// Requests which are rejected for this reason are retried with backoff until the timeout is reached, so this
+// is returned when the server remains busy for the duration of the query.
+var ErrServerBusy = errors.New("server busy")
+
+// ErrNoRows occurs when a query result which was expected to contain rows contains none.
+var ErrNoRows = errors.New("no rows")
+
+// ErrTooManyRows occurs when a query result which was expected to contain at most one row contains more.
+var ErrTooManyRows = errors.New("too many rows")
+
 // ErrQuery occurs when a server error is encountered while executing a query, excluding errors that caught by
 // ErrInvalidCredential or ErrTimeout.
 var ErrQuery = errors.New("query error")
@@ -26,9 +58,16 @@ var ErrInvalidArgument = errors.New("invalid argument")
 // ErrClosed occurs when an entity was used after it was closed.
 var ErrClosed = errors.New("closed")
 
+// ErrClusterClosed occurs when a query is executed against a Cluster, or a Database or Scope created from it,
+// after the Cluster was closed. It wraps ErrClosed.
+var ErrClusterClosed = fmt.Errorf("cluster closed: %w", ErrClosed)
+
 // ErrUnmarshal occurs when an entity could not be unmarshalled.
 var ErrUnmarshal = errors.New("unmarshalling error")
 
+// ErrQueryWarning occurs when a query returns warnings and QueryOptions.TreatWarningsAsErrors is set.
+var ErrQueryWarning = errors.New("query warning")
+
 type columnarErrorDesc struct {
 	Code    uint32
 	Message string
@@ -55,9 +94,12 @@ type ColumnarError struct {
 	message string
 
 	errors           []columnarErrorDesc
+	omittedErrors    int
 	statement        string
 	endpoint         string
 	httpResponseCode int
+
+	coreErr *gocbcore.ColumnarError
 }
 
 // nolint: unused
@@ -65,10 +107,12 @@ func newColumnarError(statement, endpoint string, statusCode int) ColumnarError
 	return ColumnarError{
 		cause:            nil,
 		errors:           nil,
+		omittedErrors:    0,
 		statement:        statement,
 		endpoint:         endpoint,
 		message:          "",
 		httpResponseCode: statusCode,
+		coreErr:          nil,
 	}
 }
 
@@ -90,17 +134,51 @@ func (e ColumnarError) withCause(cause error) *ColumnarError {
 	return &e
 }
 
+// limitErrors retains at most maxErrors of the error descriptors, recording the number which are omitted.
+// The first non-retriable descriptor is always retained, as it is the one which the error is derived from.
+func (e *ColumnarError) limitErrors(maxErrors int) {
+	if len(e.errors) <= maxErrors {
+		return
+	}
+
+	keep := -1
+
+	if e.coreErr != nil && len(e.coreErr.Errors) == len(e.errors) {
+		for i, desc := range e.coreErr.Errors {
+			if !desc.Retry {
+				keep = i
+
+				break
+			}
+		}
+	}
+
+	retained := make([]columnarErrorDesc, 0, maxErrors)
+	retained = append(retained, e.errors[:maxErrors-1]...)
+
+	if keep >= maxErrors-1 {
+		retained = append(retained, e.errors[keep])
+	} else {
+		retained = append(retained, e.errors[maxErrors-1])
+	}
+
+	e.omittedErrors += len(e.errors) - maxErrors
+	e.errors = retained
+}
+
 // Error returns the string representation of a Columnar error.
 func (e ColumnarError) Error() string {
 	errBytes, serErr := json.Marshal(struct {
 		Statement        string              `json:"statement,omitempty"`
 		Errors           []columnarErrorDesc `json:"errors,omitempty"`
+		OmittedErrors    int                 `json:"omitted_errors,omitempty"`
 		Message          string              `json:"message,omitempty"`
 		Endpoint         string              `json:"endpoint,omitempty"`
 		HTTPResponseCode int                 `json:"status_code,omitempty"`
 	}{
 		Statement:        e.statement,
 		Errors:           e.errors,
+		OmittedErrors:    e.omittedErrors,
 		Message:          e.message,
 		Endpoint:         e.endpoint,
 		HTTPResponseCode: e.httpResponseCode,
@@ -154,6 +232,70 @@ func (e QueryError) Unwrap() error {
 	return e.cause
 }
 
+// Summary returns a concise, single line description of the error, in the form
+// "code: message (n more) [endpoint: <endpoint>, status: <status>]", where n is the number of
+// additional errors returned by the server. The endpoint and status are omitted if the error has no details of the
+// response, as is the case for a zero value QueryError.
+func (e QueryError) Summary() string {
+	summary := fmt.Sprintf("%d: %s", e.code, e.message)
+	if e.cause == nil {
+		return summary
+	}
+
+	if more := len(e.cause.errors) - 1 + e.cause.omittedErrors; more > 0 {
+		summary += fmt.Sprintf(" (%d more)", more)
+	}
+
+	return summary + fmt.Sprintf(" [endpoint: %s, status: %d]", e.cause.endpoint, e.cause.httpResponseCode)
+}
+
+// QueryErrorPhase indicates the phase of query processing in which a QueryError occurred.
+type QueryErrorPhase uint
+
+const (
+	// QueryErrorPhaseUnknown indicates that the phase could not be determined from the error code.
+	QueryErrorPhaseUnknown QueryErrorPhase = iota
+	// QueryErrorPhaseParse indicates that the error occurred whilst parsing the statement.
+	QueryErrorPhaseParse
+	// QueryErrorPhasePlan indicates that the error occurred whilst compiling, or planning, the statement.
+	QueryErrorPhasePlan
+	// QueryErrorPhaseExecute indicates that the error occurred whilst executing the statement.
+	QueryErrorPhaseExecute
+)
+
+// Phase returns the phase of query processing in which the error occurred, derived from the error code.
+// Error code 24000 indicates a parse error, other codes in the 24000 range indicate compilation errors, and
+// codes in the 23000 range indicate runtime errors. Codes in the 23000 range which indicate that the service could
+// not accept the query, such as 23007 (job queue full), are not errors in processing the query and so have an
+// unknown phase.
+func (e QueryError) Phase() QueryErrorPhase {
+	switch {
+	case isServiceErrorCode(e.code):
+		return QueryErrorPhaseUnknown
+	case e.code == 24000:
+		return QueryErrorPhaseParse
+	case e.code > 24000 && e.code < 25000:
+		return QueryErrorPhasePlan
+	case e.code >= 23000 && e.code < 24000:
+		return QueryErrorPhaseExecute
+	default:
+		return QueryErrorPhaseUnknown
+	}
+}
+
+// isServiceErrorCode returns whether the error code indicates that the service was unable to accept the query,
+// rather than an error in processing it.
+func isServiceErrorCode(code int) bool {
+	switch code {
+	case 23000, // Analytics service is temporarily unavailable.
+		23003, // Operation cannot be performed during rebalance.
+		23007: // Job queue is full.
+		return true
+	default:
+		return false
+	}
+}
+
 func (e QueryError) withErrors(errors []columnarErrorDesc) *QueryError {
 	e.cause.errors = errors
 
@@ -166,16 +308,57 @@ func newQueryError(statement, endpoint string, statusCode int, code int, message
 		cause: &ColumnarError{
 			cause:            ErrQuery,
 			errors:           nil,
+			omittedErrors:    0,
 			statement:        statement,
 			endpoint:         endpoint,
 			message:          "",
 			httpResponseCode: statusCode,
+			coreErr:          nil,
 		},
 		code:    code,
 		message: message,
 	}
 }
 
+// WarningError occurs when a query returns warnings and QueryOptions.TreatWarningsAsErrors is set.
+// It wraps ErrQueryWarning.
+type WarningError struct {
+	warnings []QueryWarning
+}
+
+// Warnings returns the warnings returned by the query.
+func (e WarningError) Warnings() []QueryWarning {
+	return e.warnings
+}
+
+// Error returns the string representation of a warning error.
+func (e WarningError) Error() string {
+	descs := make([]string, len(e.warnings))
+	for i, warning := range e.warnings {
+		descs[i] = fmt.Sprintf("%d: %s", warning.Code, warning.Message)
+	}
+
+	return fmt.Sprintf("%s - %s", ErrQueryWarning.Error(), strings.Join(descs, "; "))
+}
+
+// Unwrap returns the underlying reason for the error.
+func (e WarningError) Unwrap() error {
+	return ErrQueryWarning
+}
+
+// UnwrapCore returns the underlying gocbcore error from which err was created, or nil if there is none.
+//
+// Internal: This should never be used and is not supported. It provides access to details of the error which
+// have not yet been exposed by this SDK, and may change or be removed at any time.
+func UnwrapCore(err error) *gocbcore.ColumnarError {
+	var columnarErr *ColumnarError
+	if !errors.As(err, &columnarErr) {
+		return nil
+	}
+
+	return columnarErr.coreErr
+}
+
 type invalidArgumentError struct {
 	ArgumentName string
 	Reason       string
@@ -1,8 +1,12 @@
 package cbcolumnar
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/couchbase/gocbcore/v10"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,3 +35,180 @@ func TestQueryErrorAsQueryError(t *testing.T) {
 	assert.Equal(t, 23, queryError.Code())
 	assert.Equal(t, "message", queryError.Message())
 }
+
+func TestQueryErrorSummary(t *testing.T) {
+	err := newQueryError("select *", "endpoint", 400, 24045, "Cannot find dataset").
+		withErrors([]columnarErrorDesc{
+			{Code: 24045, Message: "Cannot find dataset"},
+			{Code: 24000, Message: "Syntax error"},
+			{Code: 23000, Message: "Internal error"},
+		})
+
+	assert.Equal(t, "24045: Cannot find dataset (2 more) [endpoint: endpoint, status: 400]", err.Summary())
+
+	err = newQueryError("select *", "endpoint", 400, 24045, "Cannot find dataset").
+		withErrors([]columnarErrorDesc{
+			{Code: 24045, Message: "Cannot find dataset"},
+		})
+
+	assert.Equal(t, "24045: Cannot find dataset [endpoint: endpoint, status: 400]", err.Summary())
+
+	var zeroErr QueryError
+
+	assert.Equal(t, "0: ", zeroErr.Summary())
+}
+
+func TestTranslateGocbcoreErrorServerBusy(t *testing.T) {
+	coreErr := &gocbcore.ColumnarError{
+		InnerError: gocbcore.ErrTimeout,
+		Statement:  "select 1",
+		Errors: []gocbcore.ColumnarErrorDesc{
+			{Code: 23007, Message: "Job queue is full with [20] jobs", Retry: true},
+		},
+		LastErrorCode:    23007,
+		LastErrorMsg:     "Job queue is full with [20] jobs",
+		Endpoint:         "endpoint",
+		ErrorText:        "",
+		HTTPResponseCode: 503,
+		WasNotDispatched: false,
+	}
+
+	err := translateGocbcoreError(coreErr, 0)
+
+	require.ErrorIs(t, err, ErrServerBusy)
+	assert.NotErrorIs(t, err, ErrQuery)
+
+	var columnarErr *ColumnarError
+
+	require.ErrorAs(t, err, &columnarErr)
+	assert.Equal(t, "endpoint", columnarErr.endpoint)
+	assert.Equal(t, 503, columnarErr.httpResponseCode)
+
+	assert.Same(t, coreErr, UnwrapCore(err))
+}
+
+func TestQueryErrorPhase(t *testing.T) {
+	testCases := map[int]QueryErrorPhase{
+		24000: QueryErrorPhaseParse,
+		24001: QueryErrorPhasePlan,
+		24045: QueryErrorPhasePlan,
+		24057: QueryErrorPhasePlan,
+		23001: QueryErrorPhaseExecute,
+		23999: QueryErrorPhaseExecute,
+		23000: QueryErrorPhaseUnknown,
+		23003: QueryErrorPhaseUnknown,
+		23007: QueryErrorPhaseUnknown,
+		21002: QueryErrorPhaseUnknown,
+		25000: QueryErrorPhaseUnknown,
+		0:     QueryErrorPhaseUnknown,
+	}
+
+	for code, phase := range testCases {
+		err := newQueryError("select *", "endpoint", 400, code, "message")
+
+		assert.Equal(t, phase, err.Phase(), "code %d", code)
+	}
+}
+
+func TestUnwrapCore(t *testing.T) {
+	coreErr := &gocbcore.ColumnarError{
+		InnerError: errors.New("something went wrong"), // nolint: err113
+		Statement:  "select 1",
+		Errors: []gocbcore.ColumnarErrorDesc{
+			{Code: 24045, Message: "Cannot find dataset", Retry: false},
+		},
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         "endpoint",
+		ErrorText:        "",
+		HTTPResponseCode: 400,
+		WasNotDispatched: false,
+	}
+
+	err := translateGocbcoreError(coreErr, 0)
+
+	var queryErr *QueryError
+
+	require.ErrorAs(t, err, &queryErr)
+	assert.Same(t, coreErr, UnwrapCore(err))
+
+	assert.Nil(t, UnwrapCore(errors.New("not a columnar error"))) // nolint: err113
+	assert.Nil(t, UnwrapCore(newColumnarError("select 1", "endpoint", 200)))
+}
+
+func TestTranslateGocbcoreErrorLimitsDescriptors(t *testing.T) {
+	coreErr := &gocbcore.ColumnarError{
+		InnerError: errors.New("something went wrong"), // nolint: err113
+		Statement:  "select 1",
+		Errors: []gocbcore.ColumnarErrorDesc{
+			{Code: 23000, Message: "first", Retry: true},
+			{Code: 23000, Message: "second", Retry: true},
+			{Code: 23000, Message: "third", Retry: true},
+			{Code: 24045, Message: "Cannot find dataset", Retry: false},
+			{Code: 23000, Message: "fifth", Retry: true},
+		},
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         "endpoint",
+		ErrorText:        "",
+		HTTPResponseCode: 400,
+		WasNotDispatched: false,
+	}
+
+	err := translateGocbcoreError(coreErr, 2)
+
+	var queryErr *QueryError
+
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, 24045, queryErr.Code())
+	assert.Equal(t, []columnarErrorDesc{
+		{Code: 23000, Message: "first"},
+		{Code: 24045, Message: "Cannot find dataset"},
+	}, queryErr.cause.errors)
+	assert.Equal(t, 3, queryErr.cause.omittedErrors)
+	assert.Contains(t, err.Error(), `"omitted_errors":3`)
+	assert.Equal(t, "24045: Cannot find dataset (4 more) [endpoint: endpoint, status: 400]", queryErr.Summary())
+
+	err = translateGocbcoreError(coreErr, 0)
+
+	require.ErrorAs(t, err, &queryErr)
+	assert.Len(t, queryErr.cause.errors, 5)
+	assert.Equal(t, 0, queryErr.cause.omittedErrors)
+}
+
+type testInnerError struct {
+	reason string
+}
+
+func (e *testInnerError) Error() string {
+	return e.reason
+}
+
+func TestTranslateGocbcoreErrorPreservesInnerError(t *testing.T) {
+	innerErr := &testInnerError{reason: "failed to parse response"}
+
+	coreErr := &gocbcore.ColumnarError{
+		InnerError:       fmt.Errorf("wrapped: %w", innerErr),
+		Statement:        "select 1",
+		Errors:           nil,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         "endpoint",
+		ErrorText:        "",
+		HTTPResponseCode: 200,
+		WasNotDispatched: false,
+	}
+
+	err := translateGocbcoreError(coreErr, 0)
+
+	var columnarErr *ColumnarError
+
+	require.ErrorAs(t, err, &columnarErr)
+
+	var unwrapped *testInnerError
+
+	require.ErrorAs(t, err, &unwrapped)
+	assert.Same(t, innerErr, unwrapped)
+	require.ErrorIs(t, err, innerErr)
+	assert.Contains(t, err.Error(), "wrapped: failed to parse response")
+}
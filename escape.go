@@ -0,0 +1,11 @@
+package cbcolumnar
+
+import (
+	"strings"
+)
+
+// EscapeIdentifier escapes a SQL++ identifier, such as a database, scope or collection name, by wrapping it in
+// backticks. Any backticks within the name are doubled.
+func EscapeIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
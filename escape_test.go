@@ -0,0 +1,16 @@
+package cbcolumnar_test
+
+import (
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeIdentifier(t *testing.T) {
+	assert.Equal(t, "`travel`", cbcolumnar.EscapeIdentifier("travel"))
+	assert.Equal(t, "`travel-sample`", cbcolumnar.EscapeIdentifier("travel-sample"))
+	assert.Equal(t, "`a``b`", cbcolumnar.EscapeIdentifier("a`b"))
+	assert.Equal(t, "````", cbcolumnar.EscapeIdentifier("`"))
+	assert.Equal(t, "``", cbcolumnar.EscapeIdentifier(""))
+}
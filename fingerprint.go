@@ -0,0 +1,136 @@
+package cbcolumnar
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var fingerprintListRegexp = regexp.MustCompile(`\?(\s*,\s*\?)+`)
+
+// FingerprintStatement returns a normalized form of a SQL++ statement which is stable regardless of the literal
+// values within it, making it suitable for grouping statements, for example as a metrics label.
+// String and numeric literals are replaced with ?, lists of literals are collapsed to a single ?, comments are
+// removed and whitespace is normalized. Identifiers, keywords and parameters are left unchanged.
+// This is not a full parser and so the fingerprint of an invalid statement is not guaranteed to be meaningful.
+func FingerprintStatement(statement string) string {
+	var fingerprint strings.Builder
+
+	runes := []rune(statement)
+	pendingSpace := false
+
+	writeToken := func(token string) {
+		if pendingSpace && fingerprint.Len() > 0 {
+			fingerprint.WriteByte(' ')
+		}
+
+		pendingSpace = false
+
+		fingerprint.WriteString(token)
+	}
+
+	peek := func(i int) rune {
+		if i < len(runes) {
+			return runes[i]
+		}
+
+		return 0
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			pendingSpace = true
+			i++
+		case r == '-' && peek(i+1) == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+			pendingSpace = true
+		case r == '/' && peek(i+1) == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && peek(i+1) == '/') {
+				i++
+			}
+
+			i += 2
+			pendingSpace = true
+		case r == '\'' || r == '"':
+			i = skipQuoted(runes, i)
+
+			writeToken("?")
+		case r == '`':
+			end := skipQuoted(runes, i)
+
+			writeToken(string(runes[i:min(end, len(runes))]))
+
+			i = end
+		case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(peek(i+1))):
+			i = skipNumber(runes, i)
+
+			writeToken("?")
+		case isIdentifierRune(r):
+			start := i
+			for i < len(runes) && isIdentifierRune(runes[i]) {
+				i++
+			}
+
+			writeToken(string(runes[start:i]))
+		default:
+			writeToken(string(r))
+			i++
+		}
+	}
+
+	return fingerprintListRegexp.ReplaceAllString(fingerprint.String(), "?")
+}
+
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+// skipQuoted returns the index immediately after the quoted section starting at start.
+// Quotes can be escaped either with a backslash or by doubling them.
+func skipQuoted(runes []rune, start int) int {
+	quote := runes[start]
+
+	i := start + 1
+	for i < len(runes) {
+		switch {
+		case runes[i] == '\\':
+			i += 2
+		case runes[i] == quote && i+1 < len(runes) && runes[i+1] == quote:
+			i += 2
+		case runes[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+
+	return len(runes)
+}
+
+func skipNumber(runes []rune, start int) int {
+	i := start
+	for i < len(runes) {
+		r := runes[i]
+
+		switch {
+		case unicode.IsDigit(r) || r == '.':
+			i++
+		case (r == 'e' || r == 'E') && i+1 < len(runes):
+			i++
+			if runes[i] == '+' || runes[i] == '-' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+
+	return i
+}
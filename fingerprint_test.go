@@ -0,0 +1,28 @@
+package cbcolumnar_test
+
+import (
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintStatement(t *testing.T) {
+	testCases := map[string]string{
+		"SELECT * FROM airline WHERE id = 10":                                    "SELECT * FROM airline WHERE id = ?",
+		"SELECT *   FROM airline\n\tWHERE name = 'Air ''France''' AND x = 1.5e3": "SELECT * FROM airline WHERE name = ? AND x = ?",
+		`SELECT * FROM airline WHERE name = "United \"Air\""`:                    "SELECT * FROM airline WHERE name = ?",
+		"SELECT * FROM `travel-sample` t WHERE t.id IN [1, 2, 3]":                "SELECT * FROM `travel-sample` t WHERE t.id IN [?]",
+		"SELECT * FROM airline2 WHERE id = $id OR id = $1":                       "SELECT * FROM airline2 WHERE id = $id OR id = $1",
+		"SELECT 1 -- comment\n FROM /* another */ x":                             "SELECT ? FROM x",
+	}
+
+	for statement, expected := range testCases {
+		assert.Equal(t, expected, cbcolumnar.FingerprintStatement(statement), statement)
+	}
+
+	assert.Equal(t,
+		cbcolumnar.FingerprintStatement("SELECT * FROM airline WHERE id IN [1, 2] AND name = 'a'"),
+		cbcolumnar.FingerprintStatement("SELECT *  FROM airline WHERE id IN [4,5,6,7] AND name = 'bcd'"),
+	)
+}
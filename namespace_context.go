@@ -0,0 +1,40 @@
+package cbcolumnar
+
+import (
+	"context"
+)
+
+type namespaceContextKey struct{}
+
+// ContextWithNamespace returns a copy of ctx which carries the provided database and scope names, for use with
+// Cluster.QueryFromContext. This allows, for example, middleware to select the Scope which queries within a request
+// are executed against.
+func ContextWithNamespace(ctx context.Context, namespace *NamespaceOptions) context.Context {
+	return context.WithValue(ctx, namespaceContextKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace stored in ctx by ContextWithNamespace, if any.
+func NamespaceFromContext(ctx context.Context) (*NamespaceOptions, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	namespace, ok := ctx.Value(namespaceContextKey{}).(*NamespaceOptions)
+	if !ok || namespace == nil {
+		return nil, false
+	}
+
+	return namespace, true
+}
+
+// QueryFromContext executes the query statement on the server, tying the query context to the namespace stored in ctx
+// by ContextWithNamespace. If ctx does not carry a namespace then the query is executed against this Cluster, as with
+// ExecuteQuery.
+// In either case the query is executed using this Cluster, and so with its default options.
+func (c *Cluster) QueryFromContext(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryResult, error) {
+	if namespace, ok := NamespaceFromContext(ctx); ok {
+		return c.Database(namespace.Database).Scope(namespace.Scope).ExecuteQuery(ctx, statement, opts...)
+	}
+
+	return c.ExecuteQuery(ctx, statement, opts...)
+}
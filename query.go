@@ -2,43 +2,67 @@ package cbcolumnar
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 )
 
 // ExecuteQuery executes the query statement on the server.
 // When ExecuteQuery is called with no context.Context, or a context.Context with no Deadline, then
-// the Cluster level QueryTimeout will be applied.
+// the Cluster level DefaultOperationTimeout will be applied as the deadline if set, otherwise the Cluster level
+// QueryTimeout will be applied.
+// When ExecuteQuery is called with no context.Context, context.Background or context.TODO, and the Cluster level
+// BaseContext is set then the context.Context returned by BaseContext will be used instead.
 func (c *Cluster) ExecuteQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	queryOpts := mergeQueryOptions(opts...)
+	queryOpts := mergeQueryOptions(append([]*QueryOptions{c.defaultQueryOptions}, opts...)...)
 
 	return c.client.QueryClient().Query(ctx, statement, queryOpts)
 }
 
 // ExecuteQuery executes the query statement on the server, tying the query context to this Scope.
 // When ExecuteQuery is called with no context.Context, or a context.Context with no Deadline, then
-// the Cluster level QueryTimeout will be applied.
+// the Cluster level DefaultOperationTimeout will be applied as the deadline if set, otherwise the Cluster level
+// QueryTimeout will be applied.
+// When ExecuteQuery is called with no context.Context, context.Background or context.TODO, and the Cluster level
+// BaseContext is set then the context.Context returned by BaseContext will be used instead.
 func (s *Scope) ExecuteQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	queryOpts := mergeQueryOptions(opts...)
+	queryOpts := mergeQueryOptions(append([]*QueryOptions{s.defaultQueryOptions}, opts...)...)
 
 	return s.client.QueryClient().Query(ctx, statement, queryOpts)
 }
 
 func mergeQueryOptions(opts ...*QueryOptions) *QueryOptions {
 	queryOpts := &QueryOptions{
-		Priority:             nil,
-		PositionalParameters: nil,
-		NamedParameters:      nil,
-		ReadOnly:             nil,
-		ScanConsistency:      nil,
-		Raw:                  nil,
-		Unmarshaler:          nil,
+		Priority:                      nil,
+		PositionalParameters:          nil,
+		NamedParameters:               nil,
+		ReadOnly:                      nil,
+		ScanConsistency:               nil,
+		Raw:                           nil,
+		Unmarshaler:                   nil,
+		Sample:                        nil,
+		ConnectTimeout:                nil,
+		RowTransform:                  nil,
+		Tags:                          nil,
+		CollapseConsecutiveDuplicates: nil,
+		MaxRowBytes:                   nil,
+		RowReadTimeout:                nil,
+		ProjectFields:                 nil,
+		RowUnmarshalFunc:              nil,
+		Hints:                         nil,
+		TrackObservedKeys:             nil,
+		RowFilter:                     nil,
+		DetectMissingQueryContext:     nil,
+		AutoRestartOnConnectionLoss:   nil,
+		TreatWarningsAsErrors:         nil,
+		ParameterMarshaler:            nil,
 	}
 
 	for _, opt := range opts {
@@ -73,7 +97,176 @@ func mergeQueryOptions(opts ...*QueryOptions) *QueryOptions {
 		if opt.Unmarshaler != nil {
 			queryOpts.Unmarshaler = opt.Unmarshaler
 		}
+
+		if opt.Sample != nil {
+			queryOpts.Sample = opt.Sample
+		}
+
+		if opt.ConnectTimeout != nil {
+			queryOpts.ConnectTimeout = opt.ConnectTimeout
+		}
+
+		if opt.RowTransform != nil {
+			queryOpts.RowTransform = opt.RowTransform
+		}
+
+		if len(opt.Tags) > 0 {
+			queryOpts.Tags = opt.Tags
+		}
+
+		if opt.CollapseConsecutiveDuplicates != nil {
+			queryOpts.CollapseConsecutiveDuplicates = opt.CollapseConsecutiveDuplicates
+		}
+
+		if opt.MaxRowBytes != nil {
+			queryOpts.MaxRowBytes = opt.MaxRowBytes
+		}
+
+		if opt.RowReadTimeout != nil {
+			queryOpts.RowReadTimeout = opt.RowReadTimeout
+		}
+
+		if len(opt.ProjectFields) > 0 {
+			queryOpts.ProjectFields = opt.ProjectFields
+		}
+
+		if opt.RowUnmarshalFunc != nil {
+			queryOpts.RowUnmarshalFunc = opt.RowUnmarshalFunc
+		}
+
+		if len(opt.Hints) > 0 {
+			queryOpts.Hints = opt.Hints
+		}
+
+		if opt.TrackObservedKeys != nil {
+			queryOpts.TrackObservedKeys = opt.TrackObservedKeys
+		}
+
+		if opt.RowFilter != nil {
+			queryOpts.RowFilter = opt.RowFilter
+		}
+
+		if opt.DetectMissingQueryContext != nil {
+			queryOpts.DetectMissingQueryContext = opt.DetectMissingQueryContext
+		}
+
+		if opt.AutoRestartOnConnectionLoss != nil {
+			queryOpts.AutoRestartOnConnectionLoss = opt.AutoRestartOnConnectionLoss
+		}
+
+		if opt.TreatWarningsAsErrors != nil {
+			queryOpts.TreatWarningsAsErrors = opt.TreatWarningsAsErrors
+		}
+
+		if opt.ParameterMarshaler != nil {
+			queryOpts.ParameterMarshaler = opt.ParameterMarshaler
+		}
 	}
 
 	return queryOpts
 }
+
+// Count executes the statement on the server and returns the number of rows it produces.
+// The statement is wrapped within a COUNT query so that only the count is returned by the server, and so it must be
+// a single query beginning with SELECT, WITH or FROM. Options which apply to the rows of a result, such as RowFilter,
+// ProjectFields and Sample, are ignored.
+func (c *Cluster) Count(ctx context.Context, statement string, opts ...*QueryOptions) (int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(append([]*QueryOptions{c.defaultQueryOptions}, opts...)...)
+
+	return executeCount(ctx, c.client.QueryClient(), statement, queryOpts)
+}
+
+// Count executes the statement on the server, tying the query context to this Scope, and returns the number of
+// rows it produces.
+// See Cluster.Count for more details.
+func (s *Scope) Count(ctx context.Context, statement string, opts ...*QueryOptions) (int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(append([]*QueryOptions{s.defaultQueryOptions}, opts...)...)
+
+	return executeCount(ctx, s.client.QueryClient(), statement, queryOpts)
+}
+
+func executeCount(ctx context.Context, client queryClient, statement string, opts *QueryOptions) (int64, error) {
+	statement, err := countStatement(statement)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := client.Query(ctx, statement, countQueryOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+
+	return readCount(res)
+}
+
+func countStatement(statement string) (string, error) {
+	statement, err := subqueryStatement(statement)
+	if err != nil {
+		return "", err
+	}
+
+	// The subquery is followed by a newline so that it cannot be affected by a trailing line comment.
+	return fmt.Sprintf("SELECT VALUE COUNT(*) FROM (\n%s\n) AS counted", statement), nil
+}
+
+// countQueryOptions returns a copy of opts with the options which apply to the rows of the statement cleared, as the
+// only row returned by a count query is the count itself.
+func countQueryOptions(opts *QueryOptions) *QueryOptions {
+	countOpts := *opts
+	countOpts.Sample = nil
+	countOpts.RowTransform = nil
+	countOpts.CollapseConsecutiveDuplicates = nil
+	countOpts.MaxRowBytes = nil
+	countOpts.ProjectFields = nil
+	countOpts.RowUnmarshalFunc = nil
+	countOpts.TrackObservedKeys = nil
+	countOpts.RowFilter = nil
+
+	return &countOpts
+}
+
+func readCount(res *QueryResult) (int64, error) {
+	defer func() {
+		err := res.reader.Close()
+		if err != nil {
+			logDebugf("Failed to close count query result: %s", err)
+		}
+	}()
+
+	// The row is read from the underlying reader, so that it is unaffected by any row processing of the result.
+	rowBytes := res.reader.NextRow()
+	if rowBytes == nil {
+		err := res.Err()
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, unmarshalError{Reason: "count query returned no rows"}
+	}
+
+	var count int64
+
+	err := json.Unmarshal(rowBytes, &count)
+	if err != nil {
+		return 0, unmarshalError{Reason: "count query did not return a single integer: " + err.Error()}
+	}
+
+	if res.NextRow() != nil {
+		return 0, unmarshalError{Reason: "count query returned more than one row"}
+	}
+
+	err = res.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
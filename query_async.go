@@ -0,0 +1,63 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+)
+
+// QueryAsync executes the query statement on the server from a new goroutine, invoking callback once the first row
+// of the result is available, or once the result is known to contain no rows. If the query fails before any rows
+// are available then callback is invoked with the error instead, including when the context is cancelled.
+// If opts is nil then the default query options are used.
+func (c *Cluster) QueryAsync(ctx context.Context, statement string, opts *QueryOptions,
+	callback func(*QueryResult, error)) error {
+	return queryAsync(ctx, c.ExecuteQuery, statement, opts, callback)
+}
+
+// QueryAsync executes the query statement on the server from a new goroutine, tying the query context to this Scope.
+// See Cluster.QueryAsync for more details.
+func (s *Scope) QueryAsync(ctx context.Context, statement string, opts *QueryOptions,
+	callback func(*QueryResult, error)) error {
+	return queryAsync(ctx, s.ExecuteQuery, statement, opts, callback)
+}
+
+func queryAsync(ctx context.Context, execute executeQueryFunc, statement string, opts *QueryOptions,
+	callback func(*QueryResult, error)) error {
+	if callback == nil {
+		return invalidArgumentError{
+			ArgumentName: "callback",
+			Reason:       "callback cannot be nil",
+		}
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	go func() {
+		if err := ctx.Err(); err != nil {
+			callback(nil, err)
+
+			return
+		}
+
+		res, err := execute(ctx, statement, opts)
+		if err != nil {
+			callback(nil, err)
+
+			return
+		}
+
+		// Wait for the first row so that the callback is not invoked until the result is ready to be read.
+		err = res.ExpectNonEmpty()
+		if err != nil && !errors.Is(err, ErrNoRows) {
+			callback(nil, err)
+
+			return
+		}
+
+		callback(res, nil)
+	}()
+
+	return nil
+}
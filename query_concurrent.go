@@ -0,0 +1,146 @@
+package cbcolumnar
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// QuerySpec describes a single query to be executed by RunConcurrent.
+type QuerySpec struct {
+	Statement string
+	Options   *QueryOptions
+}
+
+// QueryOutcome contains the outcome of a single query executed by RunConcurrent.
+// If the query succeeded then Rows contains every row of the result, and MetaData the meta-data of the result,
+// otherwise Err is set.
+type QueryOutcome struct {
+	Rows     []*QueryResultRow
+	MetaData *QueryMetadata
+	Err      error
+}
+
+type executeQueryFunc func(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryResult, error)
+
+// RunConcurrent executes the queries on the server, running at most maxConcurrency of them at any one time.
+// The rows of each query are read in full before another query is started in its place.
+// The returned outcomes are in the same order as queries, the error of an individual query is returned in its
+// QueryOutcome. Once the context is done no further queries are started, the outcome of each query that was not
+// started contains the context error, and an error is returned alongside the outcomes.
+func (c *Cluster) RunConcurrent(ctx context.Context, queries []QuerySpec, maxConcurrency int) ([]QueryOutcome, error) {
+	return runConcurrent(ctx, c.ExecuteQuery, queries, maxConcurrency)
+}
+
+// RunConcurrent executes the queries on the server, tying the query context of each to this Scope, running at most
+// maxConcurrency of them at any one time.
+// See Cluster.RunConcurrent for more details.
+func (s *Scope) RunConcurrent(ctx context.Context, queries []QuerySpec, maxConcurrency int) ([]QueryOutcome, error) {
+	return runConcurrent(ctx, s.ExecuteQuery, queries, maxConcurrency)
+}
+
+func runConcurrent(ctx context.Context, execute executeQueryFunc, queries []QuerySpec,
+	maxConcurrency int) ([]QueryOutcome, error) {
+	if maxConcurrency < 1 {
+		return nil, invalidArgumentError{
+			ArgumentName: "maxConcurrency",
+			Reason:       "must be at least 1",
+		}
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	outcomes := make([]QueryOutcome, len(queries))
+	slots := make(chan struct{}, maxConcurrency)
+
+	var wg sync.WaitGroup
+
+	started := 0
+
+	for _, spec := range queries {
+		select {
+		case <-ctx.Done():
+		case slots <- struct{}{}:
+		}
+
+		// Both cases can be ready at once, so the context is checked again to guarantee that nothing is started
+		// once it is done.
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(idx int, spec QuerySpec) {
+			defer func() {
+				<-slots
+
+				wg.Done()
+			}()
+
+			outcomes[idx] = runQuerySpec(ctx, execute, spec)
+		}(started, spec)
+
+		started++
+	}
+
+	wg.Wait()
+
+	if started == len(queries) {
+		return outcomes, nil
+	}
+
+	err := fmt.Errorf("%d of %d queries were not started: %w", len(queries)-started, len(queries), ctx.Err())
+
+	for i := started; i < len(queries); i++ {
+		outcomes[i] = QueryOutcome{
+			Rows:     nil,
+			MetaData: nil,
+			Err:      err,
+		}
+	}
+
+	return outcomes, err
+}
+
+func runQuerySpec(ctx context.Context, execute executeQueryFunc, spec QuerySpec) QueryOutcome {
+	res, err := execute(ctx, spec.Statement, spec.Options)
+	if err != nil {
+		return QueryOutcome{
+			Rows:     nil,
+			MetaData: nil,
+			Err:      err,
+		}
+	}
+
+	var rows []*QueryResultRow
+	for row := res.NextRow(); row != nil; row = res.NextRow() {
+		rows = append(rows, row)
+	}
+
+	err = res.Err()
+	if err != nil {
+		return QueryOutcome{
+			Rows:     nil,
+			MetaData: nil,
+			Err:      err,
+		}
+	}
+
+	meta, err := res.MetaData()
+	if err != nil {
+		return QueryOutcome{
+			Rows:     nil,
+			MetaData: nil,
+			Err:      err,
+		}
+	}
+
+	return QueryOutcome{
+		Rows:     rows,
+		MetaData: meta,
+		Err:      nil,
+	}
+}
@@ -0,0 +1,83 @@
+package cbcolumnar
+
+// QueryCursor provides pull based access to the rows of a query result, decoding each row into T
+// as it is read using the unmarshaler configured for the query.
+//
+//	cursor := NewQueryCursor[MyType](result)
+//	for cursor.Next() {
+//		value := cursor.Value()
+//	}
+//	err := cursor.Err()
+type QueryCursor[T any] struct {
+	result *QueryResult
+	value  T
+	err    error
+}
+
+// NewQueryCursor creates a new QueryCursor over the provided result.
+func NewQueryCursor[T any](result *QueryResult) *QueryCursor[T] {
+	var value T
+
+	return &QueryCursor[T]{
+		result: result,
+		value:  value,
+		err:    nil,
+	}
+}
+
+// Next advances the cursor to the next row, decoding it into the value returned by Value.
+// Next returns false when there are no more rows or an error has occurred, Err should then be
+// checked to determine which.
+func (c *QueryCursor[T]) Next() bool {
+	if c.err != nil || c.result == nil {
+		return false
+	}
+
+	row := c.result.NextRow()
+	if row == nil {
+		return false
+	}
+
+	var value T
+
+	err := row.ContentAs(&value)
+	if err != nil {
+		c.err = err
+
+		return false
+	}
+
+	c.value = value
+
+	return true
+}
+
+// Value returns the value of the current row.
+func (c *QueryCursor[T]) Value() T {
+	return c.value
+}
+
+// Err returns any error that occurred while decoding rows, or on the underlying stream.
+func (c *QueryCursor[T]) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.result == nil {
+		return invalidArgumentError{
+			ArgumentName: "result",
+			Reason:       "result cannot be nil",
+		}
+	}
+
+	return c.result.Err()
+}
+
+// Close shuts down the underlying stream, any remaining rows are discarded.
+func (c *QueryCursor[T]) Close() error {
+	if c.result == nil {
+		return nil
+	}
+
+	return c.result.Close()
+}
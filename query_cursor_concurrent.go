@@ -0,0 +1,220 @@
+package cbcolumnar
+
+import (
+	"sync"
+)
+
+// ConcurrentQueryCursor provides pull based access to the rows of a query result, decoding rows into T
+// concurrently across a pool of workers whilst still returning values in the order in which the rows
+// were received.
+//
+// At most bufferDepth rows are held at any one time, this includes rows waiting to be decoded and rows which
+// have been decoded but are waiting on earlier rows to complete.
+type ConcurrentQueryCursor[T any] struct {
+	result *QueryResult
+
+	results    chan concurrentDecodeResult[T]
+	slots      chan struct{}
+	stop       chan struct{}
+	readerDone chan struct{}
+
+	stopOnce sync.Once
+	pending  map[int]concurrentDecodeResult[T]
+	nextIdx  int
+	value    T
+	err      error
+	finished bool
+}
+
+type concurrentDecodeJob struct {
+	idx int
+	row *QueryResultRow
+}
+
+type concurrentDecodeResult[T any] struct {
+	idx   int
+	value T
+	err   error
+}
+
+// NewConcurrentQueryCursor creates a new ConcurrentQueryCursor over the provided result, decoding rows
+// using the specified number of workers and holding at most bufferDepth rows in memory.
+func NewConcurrentQueryCursor[T any](result *QueryResult, workers, bufferDepth int) (*ConcurrentQueryCursor[T], error) {
+	if result == nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "result",
+			Reason:       "result cannot be nil",
+		}
+	}
+
+	if workers < 1 {
+		return nil, invalidArgumentError{
+			ArgumentName: "workers",
+			Reason:       "must be greater than 0",
+		}
+	}
+
+	if bufferDepth < workers {
+		return nil, invalidArgumentError{
+			ArgumentName: "bufferDepth",
+			Reason:       "must be at least the number of workers",
+		}
+	}
+
+	var value T
+
+	c := &ConcurrentQueryCursor[T]{
+		result:     result,
+		results:    make(chan concurrentDecodeResult[T], bufferDepth),
+		slots:      make(chan struct{}, bufferDepth),
+		stop:       make(chan struct{}),
+		readerDone: make(chan struct{}),
+		stopOnce:   sync.Once{},
+		pending:    make(map[int]concurrentDecodeResult[T]),
+		nextIdx:    0,
+		value:      value,
+		err:        nil,
+		finished:   false,
+	}
+
+	jobs := make(chan concurrentDecodeJob, bufferDepth)
+
+	go c.readRows(jobs)
+
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				var value T
+				err := job.row.ContentAs(&value)
+
+				c.results <- concurrentDecodeResult[T]{
+					idx:   job.idx,
+					value: value,
+					err:   err,
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(c.results)
+	}()
+
+	return c, nil
+}
+
+func (c *ConcurrentQueryCursor[T]) readRows(jobs chan<- concurrentDecodeJob) {
+	defer close(c.readerDone)
+	defer close(jobs)
+
+	for idx := 0; ; idx++ {
+		select {
+		case c.slots <- struct{}{}:
+		case <-c.stop:
+			return
+		}
+
+		row := c.result.NextRow()
+		if row == nil {
+			return
+		}
+
+		jobs <- concurrentDecodeJob{
+			idx: idx,
+			row: row,
+		}
+	}
+}
+
+// Next advances the cursor to the next row, in the order the rows were received.
+// Next returns false when there are no more rows or an error has occurred, Err should then be
+// checked to determine which.
+func (c *ConcurrentQueryCursor[T]) Next() bool {
+	if c.err != nil || c.finished {
+		return false
+	}
+
+	for {
+		if res, ok := c.pending[c.nextIdx]; ok {
+			delete(c.pending, c.nextIdx)
+			c.nextIdx++
+			<-c.slots
+
+			if res.err != nil {
+				c.err = res.err
+				c.shutdown()
+
+				return false
+			}
+
+			c.value = res.value
+
+			return true
+		}
+
+		res, ok := <-c.results
+		if !ok {
+			c.finished = true
+
+			return false
+		}
+
+		c.pending[res.idx] = res
+	}
+}
+
+// Value returns the value of the current row.
+func (c *ConcurrentQueryCursor[T]) Value() T {
+	return c.value
+}
+
+// Err returns any error that occurred while decoding rows, or on the underlying stream.
+func (c *ConcurrentQueryCursor[T]) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.result.Err()
+}
+
+// Close shuts down the cursor and the underlying stream, any remaining rows are discarded. Close waits for
+// any row read which is already in progress to complete.
+func (c *ConcurrentQueryCursor[T]) Close() error {
+	c.finished = true
+
+	return c.shutdown()
+}
+
+func (c *ConcurrentQueryCursor[T]) shutdown() error {
+	var err error
+
+	c.stopOnce.Do(func() {
+		close(c.stop)
+
+		// The reader cannot be used concurrently so wait for any in progress read to complete before closing.
+		<-c.readerDone
+
+		if c.result.reader != nil {
+			err = c.result.reader.Close()
+		}
+
+		// Drain any outstanding results so that the workers can exit.
+		go func() {
+			for range c.results {
+				select {
+				case <-c.slots:
+				default:
+				}
+			}
+		}()
+	})
+
+	return err
+}
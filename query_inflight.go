@@ -0,0 +1,66 @@
+package cbcolumnar
+
+import (
+	"context"
+	"sync"
+)
+
+type inFlightQuery struct {
+	tags   map[string]string
+	cancel context.CancelCauseFunc
+}
+
+// inFlightQueries tracks tagged queries which are currently executing, or streaming rows, so that they
+// can be canceled by tag.
+type inFlightQueries struct {
+	lock    sync.Mutex
+	nextID  uint64
+	queries map[uint64]inFlightQuery
+}
+
+func newInFlightQueries() *inFlightQueries {
+	return &inFlightQueries{
+		lock:    sync.Mutex{},
+		nextID:  0,
+		queries: make(map[uint64]inFlightQuery),
+	}
+}
+
+// add registers a query, returning a function which must be called to deregister it once the query is complete.
+func (q *inFlightQueries) add(tags map[string]string, cancel context.CancelCauseFunc) func() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	id := q.nextID
+	q.nextID++
+	q.queries[id] = inFlightQuery{
+		tags:   tags,
+		cancel: cancel,
+	}
+
+	return func() {
+		q.lock.Lock()
+		delete(q.queries, id)
+		q.lock.Unlock()
+	}
+}
+
+func (q *inFlightQueries) cancelByTag(key, value string) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var canceled int
+
+	for id, query := range q.queries {
+		if tagValue, ok := query.tags[key]; !ok || tagValue != value {
+			continue
+		}
+
+		query.cancel(ErrQueryCanceled)
+		delete(q.queries, id)
+
+		canceled++
+	}
+
+	return canceled
+}
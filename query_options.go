@@ -1,5 +1,9 @@
 package cbcolumnar
 
+import (
+	"time"
+)
+
 // QueryScanConsistency indicates the level of data consistency desired for an analytics query.
 type QueryScanConsistency uint
 
@@ -31,19 +35,117 @@ type QueryOptions struct {
 	Raw map[string]interface{}
 
 	// Unmarshaler specifies the default unmarshaler to use for decoding rows from this query.
+	// This takes precedence over any Unmarshaler carried by the context, see ContextWithUnmarshaler.
 	Unmarshaler Unmarshaler
+
+	// Sample specifies a fraction, greater than 0 and at most 1, of result rows to return.
+	// Columnar does not support sampling hints, so the statement is rewritten on the client to wrap it in a
+	// subquery which filters rows using RANDOM(). Note that the server still evaluates the original statement in
+	// full, only the volume of returned rows is reduced. Only single queries, beginning with SELECT, WITH or FROM,
+	// can be sampled, other statements are rejected with an invalid argument error.
+	Sample *float64
+
+	// ConnectTimeout specifies the maximum amount of time to spend establishing a connection for this query, if
+	// a connection is not already available. This is independent of the overall query timeout.
+	// If the timeout is exceeded then the query fails with ErrConnectTimeout. Must be greater than 0.
+	ConnectTimeout *time.Duration
+
+	// RowTransform specifies a function which is applied to the raw bytes of each row before the row is unmarshaled.
+	// This can be used to, for example, rename or redact fields. Any error returned by the function is returned when
+	// the content of the affected row is read.
+	RowTransform func([]byte) ([]byte, error)
+
+	// Tags specifies client side key/value labels for the query. Tags are not sent to the server, they
+	// identify in-flight queries, for example so that they can be canceled using Cluster.CancelByTag.
+	Tags map[string]string
+
+	// CollapseConsecutiveDuplicates specifies whether rows which are byte for byte identical to the row immediately
+	// before them should be skipped. Note that only adjacent duplicates are collapsed, duplicate rows which are separated
+	// by other rows are all returned.
+	CollapseConsecutiveDuplicates *bool
+
+	// MaxRowBytes specifies the maximum size, in bytes, of any single row. If a row exceeds this size then the
+	// query is canceled and ErrRowTooLarge is returned from QueryResult.Err. Note that the check is applied once the
+	// row has been read from the stream.
+	MaxRowBytes *int
+
+	// RowReadTimeout specifies the maximum amount of time to wait for each row to be received from the stream.
+	// The timer is reset for every row, so this bounds stalls between rows rather than the overall query duration.
+	// If the timeout is exceeded then the query is canceled and ErrRowTimeout is returned from QueryResult.Err.
+	RowReadTimeout *time.Duration
+
+	// ProjectFields specifies the top level fields to keep in each row, all other fields are removed before the
+	// row is unmarshaled. The order of the remaining fields is preserved. Rows which are not JSON objects are unaffected.
+	ProjectFields []string
+
+	// RowUnmarshalFunc specifies a function used to unmarshal rows in place of the Unmarshaler, which is also
+	// provided with the zero based index of the row within the result. This allows for decoding which depends on the
+	// position of the row. If unset then the Unmarshaler is used.
+	RowUnmarshalFunc func(index int, data []byte, out any) error
+
+	// Hints specifies optimizer hints and compiler settings for the query, for example "compiler.joinmemory". Each hint is
+	// sent as a top level parameter of the request, keeping hints separate from the statement text. Hints must not use the
+	// name of a parameter which is set by the SDK, a named parameter, or a key in Raw.
+	Hints map[string]interface{}
+
+	// TrackObservedKeys specifies whether the union of the top level keys of every object row should be tracked, this
+	// is exposed by QueryMetadata.ObservedKeys once every row has been read. This is useful for understanding the shape
+	// of heterogeneous results, but requires each row to be parsed an additional time.
+	TrackObservedKeys *bool
+
+	// RowFilter specifies a function which is applied to the raw bytes of each row, rows for which it returns false
+	// are skipped without being returned or unmarshaled. This is applied before RowTransform.
+	RowFilter func([]byte) bool
+
+	// DetectMissingQueryContext specifies whether the statement should be checked for unqualified collection
+	// references, for example "SELECT * FROM airline", when the query is not executed against a Scope and no default
+	// namespace is set. If such a reference is found then the query fails with ErrInvalidArgument rather than a less
+	// helpful error from the server. This is a heuristic rather than a full parser, so may produce false positives.
+	DetectMissingQueryContext *bool
+
+	// AutoRestartOnConnectionLoss specifies whether the query should be transparently executed again if the connection
+	// is lost whilst rows are being streamed, with rows which have already been returned being skipped. The query is
+	// restarted at most 3 times. This can only be used when ReadOnly is set, and relies on the query returning the
+	// same rows in the same order each time it is executed, so it cannot be used with Sample. Each restart is
+	// executed as a new query, and so is subject to the DefaultOperationTimeout afresh.
+	AutoRestartOnConnectionLoss *bool
+
+	// TreatWarningsAsErrors specifies that warnings returned by the query should be treated as errors. Once every row
+	// has been read, QueryResult.Err returns a WarningError if the server returned any warnings.
+	// Default = false
+	TreatWarningsAsErrors *bool
+
+	// ParameterMarshaler specifies the marshaler used to encode each of the PositionalParameters and NamedParameters.
+	// If unset then parameters are encoded as JSON using encoding/json.
+	ParameterMarshaler ParameterMarshaler
 }
 
 // NewQueryOptions creates a new instance of QueryOptions.
 func NewQueryOptions() *QueryOptions {
 	return &QueryOptions{
-		Priority:             nil,
-		PositionalParameters: nil,
-		NamedParameters:      nil,
-		ReadOnly:             nil,
-		ScanConsistency:      nil,
-		Raw:                  nil,
-		Unmarshaler:          nil,
+		Priority:                      nil,
+		PositionalParameters:          nil,
+		NamedParameters:               nil,
+		ReadOnly:                      nil,
+		ScanConsistency:               nil,
+		Raw:                           nil,
+		Unmarshaler:                   nil,
+		Sample:                        nil,
+		ConnectTimeout:                nil,
+		RowTransform:                  nil,
+		Tags:                          nil,
+		CollapseConsecutiveDuplicates: nil,
+		MaxRowBytes:                   nil,
+		RowReadTimeout:                nil,
+		ProjectFields:                 nil,
+		RowUnmarshalFunc:              nil,
+		Hints:                         nil,
+		TrackObservedKeys:             nil,
+		RowFilter:                     nil,
+		DetectMissingQueryContext:     nil,
+		AutoRestartOnConnectionLoss:   nil,
+		TreatWarningsAsErrors:         nil,
+		ParameterMarshaler:            nil,
 	}
 }
 
@@ -95,3 +197,115 @@ func (opts *QueryOptions) SetUnmarshaler(unmarshaler Unmarshaler) *QueryOptions
 
 	return opts
 }
+
+// SetSample sets the Sample field in QueryOptions.
+func (opts *QueryOptions) SetSample(sample float64) *QueryOptions {
+	opts.Sample = &sample
+
+	return opts
+}
+
+// SetConnectTimeout sets the ConnectTimeout field in QueryOptions.
+func (opts *QueryOptions) SetConnectTimeout(timeout time.Duration) *QueryOptions {
+	opts.ConnectTimeout = &timeout
+
+	return opts
+}
+
+// SetRowTransform sets the RowTransform field in QueryOptions.
+func (opts *QueryOptions) SetRowTransform(transform func([]byte) ([]byte, error)) *QueryOptions {
+	opts.RowTransform = transform
+
+	return opts
+}
+
+// SetTags sets the Tags field in QueryOptions.
+func (opts *QueryOptions) SetTags(tags map[string]string) *QueryOptions {
+	opts.Tags = tags
+
+	return opts
+}
+
+// SetCollapseConsecutiveDuplicates sets the CollapseConsecutiveDuplicates field in QueryOptions.
+func (opts *QueryOptions) SetCollapseConsecutiveDuplicates(collapse bool) *QueryOptions {
+	opts.CollapseConsecutiveDuplicates = &collapse
+
+	return opts
+}
+
+// SetMaxRowBytes sets the MaxRowBytes field in QueryOptions.
+func (opts *QueryOptions) SetMaxRowBytes(maxBytes int) *QueryOptions {
+	opts.MaxRowBytes = &maxBytes
+
+	return opts
+}
+
+// SetRowReadTimeout sets the RowReadTimeout field in QueryOptions.
+func (opts *QueryOptions) SetRowReadTimeout(timeout time.Duration) *QueryOptions {
+	opts.RowReadTimeout = &timeout
+
+	return opts
+}
+
+// SetProjectFields sets the ProjectFields field in QueryOptions.
+func (opts *QueryOptions) SetProjectFields(fields []string) *QueryOptions {
+	opts.ProjectFields = fields
+
+	return opts
+}
+
+// SetRowUnmarshalFunc sets the RowUnmarshalFunc field in QueryOptions.
+func (opts *QueryOptions) SetRowUnmarshalFunc(unmarshalFunc func(index int, data []byte, out any) error) *QueryOptions {
+	opts.RowUnmarshalFunc = unmarshalFunc
+
+	return opts
+}
+
+// SetHints sets the Hints field in QueryOptions.
+func (opts *QueryOptions) SetHints(hints map[string]interface{}) *QueryOptions {
+	opts.Hints = hints
+
+	return opts
+}
+
+// SetTrackObservedKeys sets the TrackObservedKeys field in QueryOptions.
+func (opts *QueryOptions) SetTrackObservedKeys(track bool) *QueryOptions {
+	opts.TrackObservedKeys = &track
+
+	return opts
+}
+
+// SetRowFilter sets the RowFilter field in QueryOptions.
+func (opts *QueryOptions) SetRowFilter(filter func([]byte) bool) *QueryOptions {
+	opts.RowFilter = filter
+
+	return opts
+}
+
+// SetDetectMissingQueryContext sets the DetectMissingQueryContext field in QueryOptions.
+func (opts *QueryOptions) SetDetectMissingQueryContext(detect bool) *QueryOptions {
+	opts.DetectMissingQueryContext = &detect
+
+	return opts
+}
+
+// SetAutoRestartOnConnectionLoss sets the AutoRestartOnConnectionLoss field in QueryOptions.
+func (opts *QueryOptions) SetAutoRestartOnConnectionLoss(restart bool) *QueryOptions {
+	opts.AutoRestartOnConnectionLoss = &restart
+
+	return opts
+}
+
+// SetTreatWarningsAsErrors sets the TreatWarningsAsErrors field in QueryOptions.
+func (opts *QueryOptions) SetTreatWarningsAsErrors(treatAsErrors bool) *QueryOptions {
+	opts.TreatWarningsAsErrors = &treatAsErrors
+
+	return opts
+}
+
+// SetParameterMarshaler sets the ParameterMarshaler field in QueryOptions.
+func (opts *QueryOptions) SetParameterMarshaler(marshaler ParameterMarshaler) *QueryOptions {
+	opts.ParameterMarshaler = marshaler
+
+	return opts
+}
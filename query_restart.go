@@ -0,0 +1,149 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// maxQueryRestarts is the maximum number of times that a query is restarted by
+// QueryOptions.AutoRestartOnConnectionLoss.
+const maxQueryRestarts = 3
+
+// restartingRowReader executes a query again when the connection is lost whilst rows are being streamed, skipping
+// any rows which have already been returned.
+type restartingRowReader struct {
+	reader       RowReader
+	restart      func() (RowReader, error)
+	restartsLeft int
+
+	emitted int
+	skip    int
+	err     error
+}
+
+func newRestartingRowReader(reader RowReader, maxRestarts int, restart func() (RowReader, error)) *restartingRowReader {
+	return &restartingRowReader{
+		reader:       reader,
+		restart:      restart,
+		restartsLeft: maxRestarts,
+		emitted:      0,
+		skip:         0,
+		err:          nil,
+	}
+}
+
+func (r *restartingRowReader) NextRow() []byte {
+	for {
+		if r.err != nil {
+			return nil
+		}
+
+		row := r.reader.NextRow()
+		if row != nil {
+			if r.skip > 0 {
+				r.skip--
+
+				continue
+			}
+
+			r.emitted++
+
+			return row
+		}
+
+		if r.restartAfterConnectionLoss() {
+			continue
+		}
+
+		if r.skip > 0 && r.reader.Err() == nil {
+			r.err = newColumnarError("", "", 0).
+				withMessage(fmt.Sprintf("restarted query returned fewer rows than the %d already read", r.emitted))
+		}
+
+		return nil
+	}
+}
+
+// restartAfterConnectionLoss restarts the query if the stream failed due to the connection being lost, returning
+// whether the query was restarted.
+func (r *restartingRowReader) restartAfterConnectionLoss() bool {
+	streamErr := r.reader.Err()
+	if streamErr == nil || r.restartsLeft <= 0 || !isConnectionLoss(streamErr) {
+		return false
+	}
+
+	r.restartsLeft--
+
+	logDebugf("Connection lost after reading %d rows, restarting query: %s", r.emitted, streamErr)
+
+	err := r.reader.Close()
+	if err != nil {
+		logDebugf("Failed to close reader after connection loss: %s", err)
+	}
+
+	reader, err := r.restart()
+	if err != nil {
+		r.err = err
+
+		return false
+	}
+
+	r.reader = reader
+	r.skip = r.emitted
+
+	return true
+}
+
+func isConnectionLoss(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.As(err, &opErr) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, http2Msg := range http2ConnectionLossMessages {
+		if strings.Contains(msg, http2Msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// http2ConnectionLossMessages are the messages of the errors returned by the HTTP/2 transport within net/http when
+// a stream is reset or the connection is lost. These errors are unexported, so can only be matched by message.
+var http2ConnectionLossMessages = []string{
+	"stream error: ",
+	"http2: server sent GOAWAY and closed the connection",
+	"http2: Transport received Server's graceful shutdown GOAWAY",
+	"http2: client connection lost",
+}
+
+func (r *restartingRowReader) MetaData() (*QueryMetadata, error) {
+	return r.reader.MetaData()
+}
+
+func (r *restartingRowReader) RawMetaData() ([]byte, error) {
+	return rawMetaData(r.reader)
+}
+
+func (r *restartingRowReader) Close() error {
+	return r.reader.Close()
+}
+
+func (r *restartingRowReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.reader.Err()
+}
@@ -0,0 +1,135 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingRowReader struct {
+	rows []string
+	err  error
+}
+
+func (r *failingRowReader) NextRow() []byte {
+	if len(r.rows) == 0 {
+		return nil
+	}
+
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+
+	return []byte(row)
+}
+
+func (r *failingRowReader) MetaData() (*QueryMetadata, error) {
+	return nil, r.err
+}
+
+func (r *failingRowReader) Close() error {
+	return nil
+}
+
+func (r *failingRowReader) Err() error {
+	if len(r.rows) > 0 {
+		return nil
+	}
+
+	return r.err
+}
+
+func TestRestartingRowReader(t *testing.T) {
+	restarts := 0
+
+	reader := newRestartingRowReader(&failingRowReader{rows: []string{"1", "2"}, err: io.ErrUnexpectedEOF}, maxQueryRestarts,
+		func() (RowReader, error) {
+			restarts++
+			if restarts == 1 {
+				return &failingRowReader{rows: []string{"1", "2", "3"}, err: io.ErrUnexpectedEOF}, nil
+			}
+
+			return &failingRowReader{rows: []string{"1", "2", "3", "4"}, err: nil}, nil
+		})
+
+	values, _, err := BufferQueryResult[int](NewQueryResult(reader, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 3, 4}, values)
+	assert.Equal(t, 2, restarts)
+}
+
+func TestRestartingRowReaderLimit(t *testing.T) {
+	restarts := 0
+
+	reader := newRestartingRowReader(&failingRowReader{rows: []string{"1"}, err: io.ErrUnexpectedEOF}, 2,
+		func() (RowReader, error) {
+			restarts++
+
+			return &failingRowReader{rows: []string{"1"}, err: io.ErrUnexpectedEOF}, nil
+		})
+
+	_, _, err := BufferQueryResult[int](NewQueryResult(reader, nil))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	assert.Equal(t, 2, restarts)
+}
+
+func TestRestartingRowReaderFewerRows(t *testing.T) {
+	reader := newRestartingRowReader(&failingRowReader{rows: []string{"1", "2"}, err: io.ErrUnexpectedEOF}, maxQueryRestarts,
+		func() (RowReader, error) {
+			return &failingRowReader{rows: []string{"1"}, err: nil}, nil
+		})
+
+	_, _, err := BufferQueryResult[int](NewQueryResult(reader, nil))
+	require.ErrorIs(t, err, ErrColumnar)
+}
+
+func TestIsConnectionLossHTTP2(t *testing.T) {
+	t.Run("Stream Reset", func(tt *testing.T) {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"results":[1,`))
+			w.(http.Flusher).Flush()
+
+			// Aborting the handler resets the stream part way through the response.
+			panic(http.ErrAbortHandler)
+		}))
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+
+		defer srv.Close()
+
+		resp, err := srv.Client().Get(srv.URL) // nolint: noctx
+		require.NoError(tt, err)
+
+		defer resp.Body.Close()
+
+		require.Equal(tt, 2, resp.ProtoMajor)
+
+		_, err = io.ReadAll(resp.Body)
+		require.Error(tt, err)
+
+		assert.True(tt, isConnectionLoss(err), err.Error())
+	})
+
+	t.Run("Messages", func(tt *testing.T) {
+		for _, err := range []error{
+			errors.New("http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR, debug=\"\""), // nolint: err113
+			errors.New("http2: Transport received Server's graceful shutdown GOAWAY"),                                       // nolint: err113
+			errors.New("http2: client connection lost"),                                                                     // nolint: err113
+		} {
+			assert.True(tt, isConnectionLoss(fmt.Errorf("failed to read rows: %w", err)), err.Error())
+		}
+	})
+
+	t.Run("Not Connection Loss", func(tt *testing.T) {
+		assert.False(tt, isConnectionLoss(context.Canceled))
+		assert.False(tt, isConnectionLoss(errors.New("some other error"))) // nolint: err113
+	})
+}
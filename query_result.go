@@ -1,6 +1,11 @@
 package cbcolumnar
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
 	"time"
 )
 
@@ -24,25 +29,192 @@ type QueryMetadata struct {
 	RequestID string
 	Metrics   QueryMetrics
 	Warnings  []QueryWarning
+
+	// ObservedKeys contains the sorted union of the top level keys of every object row in the result.
+	// This is only populated when QueryOptions.TrackObservedKeys is set, and only once every row has been read.
+	ObservedKeys []string
+
+	// QueryContext is the query_context which was sent with the query, redacted according to the log redaction
+	// level. This is empty for queries which were not tied to a scope.
+	QueryContext string
+
+	// EndpointsContacted contains the host and port of each endpoint which the query was dispatched to, in the order
+	// in which they were first contacted. This includes endpoints contacted by requests which were retried.
+	// This is empty when the information is not available, such as for custom RowReader implementations.
+	EndpointsContacted []string
 }
 
 // QueryResult allows access to the results of a query.
 type QueryResult struct {
-	reader analyticsRowReader
+	reader RowReader
+
+	unmarshaler      Unmarshaler
+	rowUnmarshalFunc func(int, []byte, any) error
+	rowTransform     func([]byte) ([]byte, error)
+	rowFilter        func([]byte) bool
+	rowIndex         int
+
+	collapseDuplicates bool
+	lastRow            []byte
+
+	maxRowBytes   int
+	projectFields map[string]struct{}
+	observedKeys  map[string]struct{}
+
+	treatWarningsAsErrors bool
+	err                   error
 
-	unmarshaler Unmarshaler
+	// peekedRow is the row read by ExpectNonEmpty, which is returned by the next call to NextRow.
+	peekedRow *QueryResultRow
+}
+
+// NewQueryResult creates a new QueryResult which reads rows from the provided RowReader.
+// If unmarshaler is nil then a JSONUnmarshaler is used.
+func NewQueryResult(reader RowReader, unmarshaler Unmarshaler) *QueryResult {
+	if unmarshaler == nil {
+		unmarshaler = NewJSONUnmarshaler()
+	}
+
+	return &QueryResult{
+		reader:           reader,
+		unmarshaler:      unmarshaler,
+		rowUnmarshalFunc: nil,
+		rowTransform:     nil,
+		rowFilter:        nil,
+		rowIndex:         0,
+
+		collapseDuplicates: false,
+		lastRow:            nil,
+
+		maxRowBytes:   0,
+		projectFields: nil,
+		observedKeys:  nil,
+
+		treatWarningsAsErrors: false,
+		err:                   nil,
+
+		peekedRow: nil,
+	}
 }
 
 // NextRow returns the next row in the result set, or nil if there are no more rows.
 func (r *QueryResult) NextRow() *QueryResultRow {
-	rowBytes := r.reader.NextRow()
+	if r.peekedRow != nil {
+		row := r.peekedRow
+		r.peekedRow = nil
+
+		return row
+	}
+
+	rowBytes := r.nextRawRow()
 	if rowBytes == nil {
+		if r.treatWarningsAsErrors {
+			r.checkWarnings()
+		}
+
 		return nil
 	}
 
+	if r.maxRowBytes > 0 && len(rowBytes) > r.maxRowBytes {
+		r.err = newColumnarError("", "", 0).
+			withMessage(fmt.Sprintf("row of %d bytes exceeds the maximum of %d bytes", len(rowBytes), r.maxRowBytes)).
+			withCause(ErrRowTooLarge)
+
+		err := r.reader.Close()
+		if err != nil {
+			logDebugf("Failed to close reader after oversized row: %s", err)
+		}
+
+		return nil
+	}
+
+	var err error
+	if r.rowTransform != nil {
+		rowBytes, err = r.rowTransform(rowBytes)
+	}
+
+	if err == nil && len(r.projectFields) > 0 {
+		rowBytes, err = projectRowFields(rowBytes, r.projectFields)
+	}
+
+	if err == nil && r.observedKeys != nil {
+		observeRowKeys(rowBytes, r.observedKeys)
+	}
+
+	index := r.rowIndex
+	r.rowIndex++
+
 	return &QueryResultRow{
-		rowBytes:    rowBytes,
-		unmarshaler: r.unmarshaler,
+		rowBytes:      rowBytes,
+		index:         index,
+		unmarshaler:   r.unmarshaler,
+		unmarshalFunc: r.rowUnmarshalFunc,
+		err:           err,
+	}
+}
+
+// NextValue returns the content of the next row in the result set decoded into a generic value, such as a
+// map[string]interface{}, using the unmarshaler of the result. Once there are no more rows io.EOF is returned,
+// unless an error occurred on the stream in which case that error is returned.
+func (r *QueryResult) NextValue() (any, error) {
+	row := r.NextRow()
+	if row == nil {
+		err := r.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	var value any
+
+	err := row.ContentAs(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// nextRawRow returns the bytes of the next row which is neither a collapsed duplicate nor rejected by the row filter.
+func (r *QueryResult) nextRawRow() []byte {
+	for {
+		rowBytes := r.reader.NextRow()
+		if rowBytes == nil {
+			return nil
+		}
+
+		if r.collapseDuplicates {
+			duplicate := bytes.Equal(rowBytes, r.lastRow)
+			r.lastRow = rowBytes
+
+			if duplicate {
+				continue
+			}
+		}
+
+		if r.rowFilter != nil && !r.rowFilter(rowBytes) {
+			continue
+		}
+
+		return rowBytes
+	}
+}
+
+// checkWarnings sets the error of the result if the completed stream returned any warnings.
+func (r *QueryResult) checkWarnings() {
+	if r.err != nil || r.reader.Err() != nil {
+		return
+	}
+
+	meta, err := r.reader.MetaData()
+	if err != nil || len(meta.Warnings) == 0 {
+		return
+	}
+
+	r.err = &WarningError{
+		warnings: meta.Warnings,
 	}
 }
 
@@ -52,6 +224,10 @@ func (r *QueryResult) Err() error {
 		return ErrClosed
 	}
 
+	if r.err != nil {
+		return r.err
+	}
+
 	err := r.reader.Err()
 	if err != nil {
 		return err
@@ -60,6 +236,17 @@ func (r *QueryResult) Err() error {
 	return nil
 }
 
+// Close shuts down the underlying stream, any remaining rows are discarded.
+func (r *QueryResult) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+
+	r.peekedRow = nil
+
+	return r.reader.Close()
+}
+
 // MetaData returns any meta-data that was available from this query.  Note that
 // the meta-data will only be available once the object has been closed (either
 // implicitly or explicitly).
@@ -69,23 +256,175 @@ func (r *QueryResult) MetaData() (*QueryMetadata, error) {
 		return nil, err
 	}
 
+	if r.observedKeys != nil {
+		// The reader may share the meta-data between results, so a copy is modified.
+		metaCopy := *meta
+		metaCopy.ObservedKeys = make([]string, 0, len(r.observedKeys))
+
+		for key := range r.observedKeys {
+			metaCopy.ObservedKeys = append(metaCopy.ObservedKeys, key)
+		}
+
+		sort.Strings(metaCopy.ObservedKeys)
+
+		return &metaCopy, nil
+	}
+
 	return meta, nil
 }
 
+// RawMetaData returns the meta-data of this query as the unparsed JSON returned by the server, providing access to
+// fields which are not yet exposed by QueryMetadata. As with MetaData, it is only available once all rows have been
+// read. Custom RowReader implementations can support this by implementing a RawMetaData() ([]byte, error) method,
+// otherwise an error wrapping ErrInvalidArgument is returned.
+func (r *QueryResult) RawMetaData() ([]byte, error) {
+	return rawMetaData(r.reader)
+}
+
+type rawMetaDataReader interface {
+	RawMetaData() ([]byte, error)
+}
+
+func rawMetaData(reader RowReader) ([]byte, error) {
+	rawReader, ok := reader.(rawMetaDataReader)
+	if !ok {
+		return nil, invalidArgumentError{
+			ArgumentName: "reader",
+			Reason:       "RowReader does not provide raw meta-data",
+		}
+	}
+
+	// We don't need to convert this error, if it's ours then we already have.
+	// If it's from a user provided reader then we don't want to interfere with it.
+	return rawReader.RawMetaData() // nolint:wrapcheck
+}
+
+// ExpectNonEmpty checks that the result contains at least one row, returning ErrNoRows if it does not.
+// Rows are read from the stream until one is returned by NextRow, so rows rejected by QueryOptions.RowFilter are
+// not counted, and that row is still returned by the next call to NextRow.
+// If the stream fails before a row is returned then the error is returned.
+func (r *QueryResult) ExpectNonEmpty() error {
+	if r.peekedRow != nil {
+		return nil
+	}
+
+	row := r.NextRow()
+	if row == nil {
+		err := r.Err()
+		if err != nil {
+			return err
+		}
+
+		return ErrNoRows
+	}
+
+	r.peekedRow = row
+
+	return nil
+}
+
 // QueryResultRow encapsulates a single row of a query result.
 type QueryResultRow struct {
 	rowBytes []byte
+	index    int
 
-	unmarshaler Unmarshaler
+	unmarshaler   Unmarshaler
+	unmarshalFunc func(int, []byte, any) error
+	err           error
 }
 
 // ContentAs will attempt to unmarshal the content of the row into the provided value pointer.
+// If a QueryOptions.RowTransform failed for this row then the error from the transform is returned.
+// If QueryOptions.RowUnmarshalFunc is set then it is used in place of the unmarshaler.
 func (qrr *QueryResultRow) ContentAs(valuePtr any) error {
+	if qrr.err != nil {
+		return qrr.err
+	}
+
+	if qrr.unmarshalFunc != nil {
+		// As with the unmarshaler, errors from the user's function are returned untouched.
+		return qrr.unmarshalFunc(qrr.index, qrr.rowBytes, valuePtr)
+	}
+
 	// We don't need to convert this error, if it's ours then we already have.
 	// If it's the users then we don't want to interfere with it.
 	return qrr.unmarshaler.Unmarshal(qrr.rowBytes, &valuePtr) // nolint:wrapcheck
 }
 
+func projectRowFields(rowBytes []byte, fields map[string]struct{}) ([]byte, error) {
+	trimmed := bytes.TrimSpace(rowBytes)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return rowBytes, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+
+	// Consume the opening brace of the object.
+	if _, err := decoder.Token(); err != nil {
+		return nil, unmarshalError{Reason: err.Error()}
+	}
+
+	var projected bytes.Buffer
+
+	projected.WriteByte('{')
+
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, unmarshalError{Reason: err.Error()}
+		}
+
+		var value json.RawMessage
+
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, unmarshalError{Reason: err.Error()}
+		}
+
+		key, _ := keyToken.(string)
+		if _, ok := fields[key]; !ok {
+			continue
+		}
+
+		if projected.Len() > 1 {
+			projected.WriteByte(',')
+		}
+
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, unmarshalError{Reason: err.Error()}
+		}
+
+		projected.Write(keyBytes)
+		projected.WriteByte(':')
+		projected.Write(value)
+	}
+
+	projected.WriteByte('}')
+
+	return projected.Bytes(), nil
+}
+
+// observeRowKeys adds the top level keys of the row to keys, if the row is an object.
+// Rows which cannot be parsed are ignored, the error is returned when the content of the row is read.
+func observeRowKeys(rowBytes []byte, keys map[string]struct{}) {
+	trimmed := bytes.TrimSpace(rowBytes)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return
+	}
+
+	var row map[string]json.RawMessage
+
+	err := json.Unmarshal(trimmed, &row)
+	if err != nil {
+		return
+	}
+
+	for key := range row {
+		keys[key] = struct{}{}
+	}
+}
+
 // BufferQueryResult will buffer all rows in the result set into memory and return them as a slice, with any metadata.
 func BufferQueryResult[T any](result *QueryResult) ([]T, *QueryMetadata, error) {
 	if result == nil {
@@ -121,9 +460,109 @@ func BufferQueryResult[T any](result *QueryResult) ([]T, *QueryMetadata, error)
 	return buffered, meta, nil
 }
 
-type analyticsRowReader interface {
+// Fold decodes each row of the result into T and combines it into an accumulator using f, starting from init,
+// without holding every row in memory. Iteration stops at the first decode or fold error, in which case the
+// result is closed and the error returned along with the accumulator as it was before the failing row.
+// Otherwise the final accumulator is returned along with any error from the stream.
+func Fold[T, A any](result *QueryResult, init A, f func(A, T) (A, error)) (A, error) {
+	if result == nil {
+		return init, invalidArgumentError{
+			ArgumentName: "result",
+			Reason:       "result cannot be nil",
+		}
+	}
+
+	acc := init
+
+	for row := result.NextRow(); row != nil; row = result.NextRow() {
+		var contentAs T
+
+		err := row.ContentAs(&contentAs)
+		if err == nil {
+			var next A
+
+			next, err = f(acc, contentAs)
+			if err == nil {
+				acc = next
+
+				continue
+			}
+		}
+
+		closeErr := result.reader.Close()
+		if closeErr != nil {
+			logDebugf("Failed to close result after fold error: %s", closeErr)
+		}
+
+		return acc, err
+	}
+
+	err := result.Err()
+	if err != nil {
+		return acc, err
+	}
+
+	return acc, nil
+}
+
+// ScalarOrDefault decodes the single row of the result into T, returning defaultValue if the result contains no rows.
+// This is useful for aggregate queries, which return no rows rather than a default when run over empty collections.
+// If the result contains more than one row then the result is closed and ErrTooManyRows is returned.
+func ScalarOrDefault[T any](result *QueryResult, defaultValue T) (T, error) {
+	if result == nil {
+		return defaultValue, invalidArgumentError{
+			ArgumentName: "result",
+			Reason:       "result cannot be nil",
+		}
+	}
+
+	row := result.NextRow()
+	if row == nil {
+		err := result.Err()
+		if err != nil {
+			return defaultValue, err
+		}
+
+		return defaultValue, nil
+	}
+
+	var value T
+
+	err := row.ContentAs(&value)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if result.NextRow() != nil {
+		closeErr := result.reader.Close()
+		if closeErr != nil {
+			logDebugf("Failed to close result after too many rows: %s", closeErr)
+		}
+
+		return defaultValue, ErrTooManyRows
+	}
+
+	err = result.Err()
+	if err != nil {
+		return defaultValue, err
+	}
+
+	return value, nil
+}
+
+// RowReader provides access to the raw rows and meta-data of a query response.
+// The default implementation is backed by gocbcore, other implementations can be provided to
+// NewQueryResult, for example to mock query responses within tests.
+type RowReader interface {
+	// NextRow returns the bytes of the next row in the result set, or nil if there are no more rows.
 	NextRow() []byte
+
+	// MetaData returns the meta-data of the query response, this is only available once all rows have been read.
 	MetaData() (*QueryMetadata, error)
+
+	// Close shuts down the reader, releasing any underlying resources.
 	Close() error
+
+	// Err returns any errors that have occurred on the stream.
 	Err() error
 }
@@ -0,0 +1,181 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CompareResultsOptions specifies options for comparing query results with CompareResults.
+type CompareResultsOptions struct {
+	// OrderSensitive specifies whether rows must appear in the same order in both results to be considered equal.
+	// Default = false
+	OrderSensitive *bool
+}
+
+// NewCompareResultsOptions creates a new instance of CompareResultsOptions.
+func NewCompareResultsOptions() *CompareResultsOptions {
+	return &CompareResultsOptions{
+		OrderSensitive: nil,
+	}
+}
+
+// SetOrderSensitive sets the OrderSensitive field in CompareResultsOptions.
+func (opts *CompareResultsOptions) SetOrderSensitive(orderSensitive bool) *CompareResultsOptions {
+	opts.OrderSensitive = &orderSensitive
+
+	return opts
+}
+
+// Difference describes a row which differs between two results compared by CompareResults.
+// Rows are normalized JSON, with object keys sorted and insignificant whitespace removed.
+type Difference struct {
+	// Index is the position of the row within the results when comparing with OrderSensitive set, otherwise -1.
+	Index int
+
+	// A is the row from the first result, or nil if the row is only present in the second result.
+	A []byte
+
+	// B is the row from the second result, or nil if the row is only present in the first result.
+	B []byte
+}
+
+// CompareResults reads every remaining row of both results and reports whether they contain the same rows,
+// along with the differences between them. By default the order of rows is ignored, with the number of times
+// each row occurs in each result being compared.
+// Rows are compared after normalizing their JSON, so differences in key order and whitespace are ignored.
+// Note that both results are buffered in memory in full.
+func CompareResults(a, b *QueryResult, opts *CompareResultsOptions) (bool, []Difference, error) {
+	if a == nil || b == nil {
+		return false, nil, invalidArgumentError{
+			ArgumentName: "result",
+			Reason:       "result cannot be nil",
+		}
+	}
+
+	if opts == nil {
+		opts = NewCompareResultsOptions()
+	}
+
+	rowsA, err := normalizedRows(a)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rowsB, err := normalizedRows(b)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var diffs []Difference
+	if opts.OrderSensitive != nil && *opts.OrderSensitive {
+		diffs = compareRowsOrdered(rowsA, rowsB)
+	} else {
+		diffs = compareRowsUnordered(rowsA, rowsB)
+	}
+
+	return len(diffs) == 0, diffs, nil
+}
+
+func normalizedRows(result *QueryResult) ([][]byte, error) {
+	var rows [][]byte
+
+	for row := result.NextRow(); row != nil; row = result.NextRow() {
+		if row.err != nil {
+			return nil, row.err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(row.rowBytes))
+		decoder.UseNumber()
+
+		var value interface{}
+
+		err := decoder.Decode(&value)
+		if err != nil {
+			return nil, unmarshalError{Reason: err.Error()}
+		}
+
+		normalized, err := json.Marshal(value)
+		if err != nil {
+			return nil, unmarshalError{Reason: err.Error()}
+		}
+
+		rows = append(rows, normalized)
+	}
+
+	err := result.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func compareRowsOrdered(rowsA, rowsB [][]byte) []Difference {
+	var diffs []Difference
+
+	for i := 0; i < max(len(rowsA), len(rowsB)); i++ {
+		var rowA, rowB []byte
+		if i < len(rowsA) {
+			rowA = rowsA[i]
+		}
+
+		if i < len(rowsB) {
+			rowB = rowsB[i]
+		}
+
+		if !bytes.Equal(rowA, rowB) {
+			diffs = append(diffs, Difference{
+				Index: i,
+				A:     rowA,
+				B:     rowB,
+			})
+		}
+	}
+
+	return diffs
+}
+
+func compareRowsUnordered(rowsA, rowsB [][]byte) []Difference {
+	counts := make(map[string]int)
+
+	// Rows are reported in the order in which they first appear, first in a and then in b.
+	var order []string
+
+	for _, row := range rowsA {
+		if _, ok := counts[string(row)]; !ok {
+			order = append(order, string(row))
+		}
+
+		counts[string(row)]++
+	}
+
+	for _, row := range rowsB {
+		if _, ok := counts[string(row)]; !ok {
+			order = append(order, string(row))
+		}
+
+		counts[string(row)]--
+	}
+
+	var diffs []Difference
+
+	for _, row := range order {
+		for count := counts[row]; count > 0; count-- {
+			diffs = append(diffs, Difference{
+				Index: -1,
+				A:     []byte(row),
+				B:     nil,
+			})
+		}
+
+		for count := counts[row]; count < 0; count++ {
+			diffs = append(diffs, Difference{
+				Index: -1,
+				A:     nil,
+				B:     []byte(row),
+			})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,189 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// CSVOptions specifies options for writing a query result as CSV.
+type CSVOptions struct {
+	// Columns specifies the fields to write, and the order in which to write them.
+	// If not set then the fields of the first row are used, in the order in which they appear.
+	Columns []string
+
+	// Delimiter specifies the field delimiter.
+	// Default = ','
+	Delimiter rune
+}
+
+// NewCSVOptions creates a new instance of CSVOptions.
+func NewCSVOptions() *CSVOptions {
+	return &CSVOptions{
+		Columns:   nil,
+		Delimiter: 0,
+	}
+}
+
+// SetColumns sets the Columns field in CSVOptions.
+func (opts *CSVOptions) SetColumns(columns []string) *CSVOptions {
+	opts.Columns = columns
+
+	return opts
+}
+
+// SetDelimiter sets the Delimiter field in CSVOptions.
+func (opts *CSVOptions) SetDelimiter(delimiter rune) *CSVOptions {
+	opts.Delimiter = delimiter
+
+	return opts
+}
+
+// WriteCSV writes every remaining row in the result to w as CSV, preceded by a header row, returning the number of
+// rows written, excluding the header. If the result contains no rows then the header is only written if
+// CSVOptions.Columns is set.
+// Each row must be a JSON object. String fields are written as their value, nested objects and arrays are written
+// as JSON, missing and null fields are written as empty cells.
+func (r *QueryResult) WriteCSV(w io.Writer, opts *CSVOptions) (int64, error) {
+	if opts == nil {
+		opts = NewCSVOptions()
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+
+	written, err := r.writeCSVRows(writer, opts.Columns)
+
+	// Rows which were written before any error must still reach w.
+	writer.Flush()
+
+	if err != nil {
+		return written, err
+	}
+
+	err = writer.Error()
+	if err != nil {
+		return written, wrapCSVError(err)
+	}
+
+	return written, r.Err()
+}
+
+func (r *QueryResult) writeCSVRows(writer *csv.Writer, columns []string) (int64, error) {
+	var written int64
+
+	for row := r.NextRow(); row != nil; row = r.NextRow() {
+		if row.err != nil {
+			return written, row.err
+		}
+
+		keys, fields, err := decodeCSVRow(row.rowBytes)
+		if err != nil {
+			return written, err
+		}
+
+		if written == 0 {
+			if len(columns) == 0 {
+				columns = keys
+			}
+
+			err = writer.Write(columns)
+			if err != nil {
+				return written, wrapCSVError(err)
+			}
+		}
+
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = csvCell(fields[column])
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return written, wrapCSVError(err)
+		}
+
+		written++
+	}
+
+	// If there were no rows then the header can still be written if the columns were provided.
+	if written == 0 && len(columns) > 0 {
+		err := writer.Write(columns)
+		if err != nil {
+			return written, wrapCSVError(err)
+		}
+	}
+
+	return written, nil
+}
+
+func decodeCSVRow(rowBytes []byte) ([]string, map[string]json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(rowBytes))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, unmarshalError{Reason: err.Error()}
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil, unmarshalError{Reason: "row is not a JSON object"}
+	}
+
+	var keys []string
+
+	fields := make(map[string]json.RawMessage)
+
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, nil, unmarshalError{Reason: err.Error()}
+		}
+
+		var value json.RawMessage
+
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, nil, unmarshalError{Reason: err.Error()}
+		}
+
+		key, _ := keyToken.(string)
+		keys = append(keys, key)
+		fields[key] = value
+	}
+
+	return keys, fields, nil
+}
+
+func csvCell(value json.RawMessage) string {
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+
+	if value[0] == '"' {
+		var str string
+		if err := json.Unmarshal(value, &str); err == nil {
+			return str
+		}
+	}
+
+	return string(value)
+}
+
+type csvError struct {
+	err error
+}
+
+func (e csvError) Error() string {
+	return "failed to write csv - " + e.err.Error()
+}
+
+func (e csvError) Unwrap() error {
+	return e.err
+}
+
+func wrapCSVError(err error) error {
+	return csvError{err: err}
+}
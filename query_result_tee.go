@@ -0,0 +1,161 @@
+package cbcolumnar
+
+import (
+	"sync"
+)
+
+// Tee splits the result into n results which each independently stream every row of this result, without
+// re-executing the query. The original result must not be used once Tee has been called.
+//
+// Rows are read from the server as the fastest consumer requests them, and are buffered in memory until every
+// other consumer has read them. A slow, or abandoned, consumer will therefore cause rows to accumulate in memory.
+// Each result should be read until NextRow returns nil, which releases the underlying stream once every row has
+// been read. A result which is no longer needed should be closed using QueryResult.Close, so that rows are no longer
+// buffered for it, the underlying stream is closed once every result has been closed. Canceling the context passed
+// to ExecuteQuery ends the stream for every result.
+// Returns nil if n is less than 1.
+func (r *QueryResult) Tee(n int) []*QueryResult {
+	if n < 1 {
+		return nil
+	}
+
+	source := &teeSource{
+		lock:    sync.Mutex{},
+		reader:  r.reader,
+		buffers: make([][][]byte, n),
+		closed:  make([]bool, n),
+		open:    n,
+	}
+
+	results := make([]*QueryResult, n)
+	for i := range results {
+		var observedKeys map[string]struct{}
+		if r.observedKeys != nil {
+			observedKeys = make(map[string]struct{}, len(r.observedKeys))
+			for key := range r.observedKeys {
+				observedKeys[key] = struct{}{}
+			}
+		}
+
+		// A row peeked by ExpectNonEmpty has already been read from the stream, so is returned by every result.
+		var peekedRow *QueryResultRow
+		if r.peekedRow != nil {
+			rowCopy := *r.peekedRow
+			peekedRow = &rowCopy
+		}
+
+		results[i] = &QueryResult{
+			reader: &teeRowReader{
+				source: source,
+				idx:    i,
+			},
+			unmarshaler:        r.unmarshaler,
+			rowUnmarshalFunc:   r.rowUnmarshalFunc,
+			rowTransform:       r.rowTransform,
+			rowFilter:          r.rowFilter,
+			rowIndex:           r.rowIndex,
+			collapseDuplicates: r.collapseDuplicates,
+			lastRow:            r.lastRow,
+			maxRowBytes:        r.maxRowBytes,
+			projectFields:      r.projectFields,
+			observedKeys:       observedKeys,
+
+			treatWarningsAsErrors: r.treatWarningsAsErrors,
+			err:                   nil,
+
+			peekedRow: peekedRow,
+		}
+	}
+
+	return results
+}
+
+type teeSource struct {
+	lock    sync.Mutex
+	reader  RowReader
+	buffers [][][]byte
+	closed  []bool
+	open    int
+}
+
+func (s *teeSource) nextRow(idx int) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed[idx] {
+		return nil
+	}
+
+	if len(s.buffers[idx]) > 0 {
+		row := s.buffers[idx][0]
+		s.buffers[idx] = s.buffers[idx][1:]
+
+		return row
+	}
+
+	row := s.reader.NextRow()
+	if row == nil {
+		return nil
+	}
+
+	for i := range s.buffers {
+		if i != idx && !s.closed[i] {
+			s.buffers[i] = append(s.buffers[i], row)
+		}
+	}
+
+	return row
+}
+
+func (s *teeSource) close(idx int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed[idx] {
+		return nil
+	}
+
+	s.closed[idx] = true
+	s.buffers[idx] = nil
+	s.open--
+
+	if s.open > 0 {
+		return nil
+	}
+
+	return s.reader.Close()
+}
+
+type teeRowReader struct {
+	source *teeSource
+	idx    int
+}
+
+func (t *teeRowReader) NextRow() []byte {
+	return t.source.nextRow(t.idx)
+}
+
+func (t *teeRowReader) MetaData() (*QueryMetadata, error) {
+	t.source.lock.Lock()
+	defer t.source.lock.Unlock()
+
+	return t.source.reader.MetaData()
+}
+
+func (t *teeRowReader) RawMetaData() ([]byte, error) {
+	t.source.lock.Lock()
+	defer t.source.lock.Unlock()
+
+	return rawMetaData(t.source.reader)
+}
+
+func (t *teeRowReader) Close() error {
+	return t.source.close(t.idx)
+}
+
+func (t *teeRowReader) Err() error {
+	t.source.lock.Lock()
+	defer t.source.lock.Unlock()
+
+	return t.source.reader.Err()
+}
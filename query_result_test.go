@@ -0,0 +1,425 @@
+package cbcolumnar_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResultCustomRowReader(t *testing.T) {
+	reader := NewMockRowReader([]string{"1", "2", "3"}, &cbcolumnar.QueryMetadata{
+		RequestID: "request",
+		Metrics: cbcolumnar.QueryMetrics{
+			ElapsedTime:      0,
+			ExecutionTime:    0,
+			ResultCount:      3,
+			ResultSize:       0,
+			ProcessedObjects: 0,
+		},
+		Warnings:           nil,
+		ObservedKeys:       nil,
+		QueryContext:       "",
+		EndpointsContacted: nil,
+	})
+
+	res := cbcolumnar.NewQueryResult(reader, nil)
+
+	rows, meta, err := cbcolumnar.BufferQueryResult[int](res)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 3}, rows)
+	assert.Equal(t, "request", meta.RequestID)
+	assert.Equal(t, uint64(3), meta.Metrics.ResultCount)
+}
+
+func TestQueryCursor(t *testing.T) {
+	reader := NewMockRowReader([]string{"1", "2", "3"}, nil)
+
+	cursor := cbcolumnar.NewQueryCursor[int](cbcolumnar.NewQueryResult(reader, nil))
+
+	var values []int
+	for cursor.Next() {
+		values = append(values, cursor.Value())
+	}
+
+	require.NoError(t, cursor.Err())
+	assert.Equal(t, []int{1, 2, 3}, values)
+
+	require.NoError(t, cursor.Close())
+	assert.True(t, reader.Closed)
+}
+
+func TestQueryCursorDecodeError(t *testing.T) {
+	reader := NewMockRowReader([]string{"1", "\"two\"", "3"}, nil)
+
+	cursor := cbcolumnar.NewQueryCursor[int](cbcolumnar.NewQueryResult(reader, nil))
+
+	var values []int
+	for cursor.Next() {
+		values = append(values, cursor.Value())
+	}
+
+	require.ErrorIs(t, cursor.Err(), cbcolumnar.ErrUnmarshal)
+	assert.Equal(t, []int{1}, values)
+}
+
+func TestConcurrentQueryCursor(t *testing.T) {
+	rows := make([]string, 100)
+	expected := make([]int, 100)
+
+	for i := range rows {
+		rows[i] = strconv.Itoa(i)
+		expected[i] = i
+	}
+
+	reader := NewMockRowReader(rows, nil)
+
+	cursor, err := cbcolumnar.NewConcurrentQueryCursor[int](cbcolumnar.NewQueryResult(reader, nil), 4, 8)
+	require.NoError(t, err)
+
+	var values []int
+	for cursor.Next() {
+		values = append(values, cursor.Value())
+	}
+
+	require.NoError(t, cursor.Err())
+	assert.Equal(t, expected, values)
+
+	require.NoError(t, cursor.Close())
+	assert.True(t, reader.Closed)
+}
+
+func TestConcurrentQueryCursorDecodeError(t *testing.T) {
+	reader := NewMockRowReader([]string{"1", "2", "\"three\"", "4", "5"}, nil)
+
+	cursor, err := cbcolumnar.NewConcurrentQueryCursor[int](cbcolumnar.NewQueryResult(reader, nil), 2, 2)
+	require.NoError(t, err)
+
+	var values []int
+	for cursor.Next() {
+		values = append(values, cursor.Value())
+	}
+
+	require.ErrorIs(t, cursor.Err(), cbcolumnar.ErrUnmarshal)
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestConcurrentQueryCursorInvalidArguments(t *testing.T) {
+	result := cbcolumnar.NewQueryResult(NewMockRowReader(nil, nil), nil)
+
+	_, err := cbcolumnar.NewConcurrentQueryCursor[int](result, 0, 1)
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	_, err = cbcolumnar.NewConcurrentQueryCursor[int](result, 4, 2)
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestQueryResultTee(t *testing.T) {
+	reader := NewMockRowReader([]string{"1", "2", "3"}, nil)
+
+	results := cbcolumnar.NewQueryResult(reader, nil).Tee(2)
+	require.Len(t, results, 2)
+
+	first, _, err := cbcolumnar.BufferQueryResult[int](results[0])
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, first)
+
+	second, _, err := cbcolumnar.BufferQueryResult[int](results[1])
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, second)
+
+	require.NoError(t, results[0].Close())
+	assert.False(t, reader.Closed)
+
+	require.NoError(t, results[1].Close())
+	assert.True(t, reader.Closed)
+
+	// A result which is closed before being read does not prevent the others from reading every row.
+	reader = NewMockRowReader([]string{"1", "2", "3"}, nil)
+
+	results = cbcolumnar.NewQueryResult(reader, nil).Tee(2)
+	require.NoError(t, results[0].Close())
+	assert.Nil(t, results[0].NextRow())
+
+	second, _, err = cbcolumnar.BufferQueryResult[int](results[1])
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, second)
+
+	require.NoError(t, results[1].Close())
+	assert.True(t, reader.Closed)
+}
+
+func TestQueryResultExpectNonEmpty(t *testing.T) {
+	result := cbcolumnar.NewQueryResult(NewMockRowReader([]string{"1", "2"}, nil), nil)
+
+	require.NoError(t, result.ExpectNonEmpty())
+
+	rows, _, err := cbcolumnar.BufferQueryResult[int](result)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, rows)
+
+	result = cbcolumnar.NewQueryResult(NewMockRowReader(nil, nil), nil)
+
+	require.ErrorIs(t, result.ExpectNonEmpty(), cbcolumnar.ErrNoRows)
+}
+
+type rawMetaRowReader struct {
+	*MockRowReader
+
+	raw []byte
+}
+
+func (r *rawMetaRowReader) RawMetaData() ([]byte, error) {
+	return r.raw, nil
+}
+
+func TestQueryResultRawMetaData(t *testing.T) {
+	raw := []byte(`{"requestID":"request","newField":true}`)
+
+	res := cbcolumnar.NewQueryResult(&rawMetaRowReader{
+		MockRowReader: NewMockRowReader([]string{"1"}, nil),
+		raw:           raw,
+	}, nil)
+
+	require.NoError(t, res.ExpectNonEmpty())
+
+	results := res.Tee(2)
+	for _, result := range results {
+		require.NotNil(t, result.NextRow())
+		require.Nil(t, result.NextRow())
+
+		meta, err := result.RawMetaData()
+		require.NoError(t, err)
+		assert.Equal(t, raw, meta)
+	}
+
+	_, err := cbcolumnar.NewQueryResult(NewMockRowReader(nil, nil), nil).RawMetaData()
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestQueryResultWriteCSV(t *testing.T) {
+	reader := NewMockRowReader([]string{
+		`{"id":1,"name":"hotel, the","address":{"city":"London"},"tags":["a","b"]}`,
+		`{"name":"inn","id":2,"free":null}`,
+	}, nil)
+
+	var buf bytes.Buffer
+
+	written, err := cbcolumnar.NewQueryResult(reader, nil).WriteCSV(&buf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), written)
+	assert.Equal(t, "id,name,address,tags\n"+
+		"1,\"hotel, the\",\"{\"\"city\"\":\"\"London\"\"}\",\"[\"\"a\"\",\"\"b\"\"]\"\n"+
+		"2,inn,,\n", buf.String())
+
+	reader = NewMockRowReader([]string{`{"id":1,"name":"hotel"}`}, nil)
+	buf.Reset()
+
+	written, err = cbcolumnar.NewQueryResult(reader, nil).
+		WriteCSV(&buf, cbcolumnar.NewCSVOptions().SetColumns([]string{"name", "missing"}).SetDelimiter(';'))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), written)
+	assert.Equal(t, "name;missing\nhotel;\n", buf.String())
+
+	// Rows written before an error are still flushed.
+	reader = NewMockRowReader([]string{`{"a":1}`, `[1]`}, nil)
+	buf.Reset()
+
+	written, err = cbcolumnar.NewQueryResult(reader, nil).WriteCSV(&buf, nil)
+	require.ErrorIs(t, err, cbcolumnar.ErrUnmarshal)
+
+	assert.Equal(t, int64(1), written)
+	assert.Equal(t, "a\n1\n", buf.String())
+}
+
+type MockRowReader struct {
+	Rows   [][]byte
+	Meta   *cbcolumnar.QueryMetadata
+	Error  error
+	Closed bool
+}
+
+func NewMockRowReader(rows []string, meta *cbcolumnar.QueryMetadata) *MockRowReader {
+	rowBytes := make([][]byte, len(rows))
+	for i, row := range rows {
+		rowBytes[i] = []byte(row)
+	}
+
+	return &MockRowReader{
+		Rows:   rowBytes,
+		Meta:   meta,
+		Error:  nil,
+		Closed: false,
+	}
+}
+
+func (r *MockRowReader) NextRow() []byte {
+	if r.Closed || len(r.Rows) == 0 {
+		return nil
+	}
+
+	row := r.Rows[0]
+	r.Rows = r.Rows[1:]
+
+	return row
+}
+
+func (r *MockRowReader) MetaData() (*cbcolumnar.QueryMetadata, error) {
+	return r.Meta, nil
+}
+
+func (r *MockRowReader) Close() error {
+	r.Closed = true
+
+	return nil
+}
+
+func (r *MockRowReader) Err() error {
+	return r.Error
+}
+
+func TestFold(t *testing.T) {
+	res := cbcolumnar.NewQueryResult(NewMockRowReader([]string{"1", "2", "3"}, nil), nil)
+
+	sum, err := cbcolumnar.Fold(res, 10, func(acc int, val int) (int, error) {
+		return acc + val, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 16, sum)
+
+	foldErr := errors.New("fold failed") // nolint: err113
+	reader := NewMockRowReader([]string{"1", "2", "3"}, nil)
+
+	sum, err = cbcolumnar.Fold(cbcolumnar.NewQueryResult(reader, nil), 0, func(acc int, val int) (int, error) {
+		if val == 2 {
+			return 0, foldErr
+		}
+
+		return acc + val, nil
+	})
+	require.ErrorIs(t, err, foldErr)
+	assert.Equal(t, 1, sum)
+	assert.True(t, reader.Closed)
+
+	_, err = cbcolumnar.Fold(cbcolumnar.NewQueryResult(NewMockRowReader([]string{"\"one\""}, nil), nil), 0,
+		func(acc int, val int) (int, error) {
+			return acc + val, nil
+		})
+	require.ErrorIs(t, err, cbcolumnar.ErrUnmarshal)
+}
+
+func TestNextValue(t *testing.T) {
+	res := cbcolumnar.NewQueryResult(NewMockRowReader([]string{`{"a":[1,"b"]}`, `2`, `{`}, nil), nil)
+
+	value, err := res.NextValue()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{float64(1), "b"}}, value)
+
+	value, err = res.NextValue()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), value)
+
+	_, err = res.NextValue()
+	require.ErrorIs(t, err, cbcolumnar.ErrUnmarshal)
+
+	_, err = res.NextValue()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestContentAsTuple(t *testing.T) {
+	type tuple struct {
+		Name  string
+		Count int
+		Tags  []string
+	}
+
+	res := cbcolumnar.NewQueryResult(NewMockRowReader([]string{
+		`["one", 1, ["a", "b"]]`,
+		`["two", 2]`,
+		`{"Name": "three"}`,
+	}, nil), nil)
+
+	var val tuple
+
+	row := res.NextRow()
+	require.NotNil(t, row)
+	require.NoError(t, row.ContentAsTuple(&val))
+	assert.Equal(t, tuple{Name: "one", Count: 1, Tags: []string{"a", "b"}}, val)
+
+	row = res.NextRow()
+	require.NotNil(t, row)
+	require.ErrorIs(t, row.ContentAsTuple(&val), cbcolumnar.ErrUnmarshal)
+
+	row = res.NextRow()
+	require.NotNil(t, row)
+	require.ErrorIs(t, row.ContentAsTuple(&val), cbcolumnar.ErrUnmarshal)
+	require.ErrorIs(t, row.ContentAsTuple(val), cbcolumnar.ErrInvalidArgument)
+}
+
+func TestScalarOrDefault(t *testing.T) {
+	value, err := cbcolumnar.ScalarOrDefault(cbcolumnar.NewQueryResult(NewMockRowReader(nil, nil), nil), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, value)
+
+	value, err = cbcolumnar.ScalarOrDefault(cbcolumnar.NewQueryResult(NewMockRowReader([]string{"3"}, nil), nil), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	reader := NewMockRowReader([]string{"3", "4"}, nil)
+
+	_, err = cbcolumnar.ScalarOrDefault(cbcolumnar.NewQueryResult(reader, nil), 7)
+	require.ErrorIs(t, err, cbcolumnar.ErrTooManyRows)
+	assert.True(t, reader.Closed)
+
+	_, err = cbcolumnar.ScalarOrDefault(cbcolumnar.NewQueryResult(NewMockRowReader([]string{`"three"`}, nil), nil), 7)
+	require.ErrorIs(t, err, cbcolumnar.ErrUnmarshal)
+}
+
+func TestCompareResults(t *testing.T) {
+	newResult := func(rows ...string) *cbcolumnar.QueryResult {
+		return cbcolumnar.NewQueryResult(NewMockRowReader(rows, nil), nil)
+	}
+
+	equal, diffs, err := cbcolumnar.CompareResults(
+		newResult(`{"a":1,"b":2}`, `{"c":3}`),
+		newResult(`{ "c": 3 }`, `{"b":2,"a":1}`),
+		nil)
+	require.NoError(t, err)
+	assert.True(t, equal)
+	assert.Empty(t, diffs)
+
+	equal, diffs, err = cbcolumnar.CompareResults(
+		newResult(`1`, `1`, `2`),
+		newResult(`1`, `3`),
+		nil)
+	require.NoError(t, err)
+	assert.False(t, equal)
+	assert.Equal(t, []cbcolumnar.Difference{
+		{Index: -1, A: []byte(`1`), B: nil},
+		{Index: -1, A: []byte(`2`), B: nil},
+		{Index: -1, A: nil, B: []byte(`3`)},
+	}, diffs)
+
+	equal, diffs, err = cbcolumnar.CompareResults(
+		newResult(`1`, `2`),
+		newResult(`2`, `1`, `3`),
+		cbcolumnar.NewCompareResultsOptions().SetOrderSensitive(true))
+	require.NoError(t, err)
+	assert.False(t, equal)
+	assert.Equal(t, []cbcolumnar.Difference{
+		{Index: 0, A: []byte(`1`), B: []byte(`2`)},
+		{Index: 1, A: []byte(`2`), B: []byte(`1`)},
+		{Index: 2, A: nil, B: []byte(`3`)},
+	}, diffs)
+
+	_, _, err = cbcolumnar.CompareResults(newResult(`{`), newResult(), nil)
+	require.ErrorIs(t, err, cbcolumnar.ErrUnmarshal)
+}
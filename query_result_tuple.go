@@ -0,0 +1,63 @@
+package cbcolumnar
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ContentAsTuple will attempt to unmarshal a row which is a JSON array into the struct pointed to by valuePtr,
+// assigning each element of the array to the exported field of the struct at the same position. Each element is
+// unmarshalled using the unmarshaler of the result.
+// An error wrapping ErrUnmarshal is returned if the row is not an array, or if the length of the array does not match
+// the number of exported fields of the struct.
+func (qrr *QueryResultRow) ContentAsTuple(valuePtr any) error {
+	if qrr.err != nil {
+		return qrr.err
+	}
+
+	value := reflect.ValueOf(valuePtr)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return invalidArgumentError{
+			ArgumentName: "valuePtr",
+			Reason:       "must be a non-nil pointer to a struct",
+		}
+	}
+
+	var elements []json.RawMessage
+
+	err := json.Unmarshal(qrr.rowBytes, &elements)
+	if err != nil {
+		return unmarshalError{Reason: err.Error()}
+	}
+
+	fields := tupleFields(value.Elem())
+	if len(elements) != len(fields) {
+		return unmarshalError{
+			Reason: fmt.Sprintf("row has %d elements but %d fields were expected", len(elements), len(fields)),
+		}
+	}
+
+	for i, field := range fields {
+		// We don't need to convert this error, if it's ours then we already have.
+		// If it's the users then we don't want to interfere with it.
+		err = qrr.unmarshaler.Unmarshal(elements[i], field.Addr().Interface()) // nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tupleFields(value reflect.Value) []reflect.Value {
+	var fields []reflect.Value
+
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).IsExported() {
+			fields = append(fields, value.Field(i))
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,113 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+// additionalRetryBackoff calculates the time to wait before retrying a query which failed with one of the
+// ClusterOptions.AdditionalRetriableCodes, it matches the backoff gocbcore uses for retriable errors.
+var additionalRetryBackoff = gocbcore.ExponentialBackoff(100*time.Millisecond, time.Minute, 2)
+
+// queryWithAdditionalRetries dispatches the query to the agent, which retries any errors that the server marks as
+// retriable. Queries which fail due to errors the server does not mark as retriable are retried here if every such
+// error has one of the additional retriable codes.
+// Retries stop once the context deadline, or the timeout sent to the server, would be exceeded. As with the retries
+// made by gocbcore, the timeout sent to the server is reduced by the time already spent on each retry.
+func (c *gocbcoreQueryClient) queryWithAdditionalRetries(ctx context.Context,
+	opts gocbcore.ColumnarQueryOptions) (*gocbcore.ColumnarRowReader, error) {
+	start := c.now()
+	serverTimeout := payloadTimeout(opts.Payload)
+
+	for retries := uint32(0); ; retries++ {
+		res, err := c.agent.Query(ctx, opts)
+		if err == nil || !isAdditionallyRetriable(err, c.additionalRetriableCodes) {
+			// The error is translated by the caller.
+			return res, err // nolint: wrapcheck
+		}
+
+		backoff := additionalRetryBackoff(retries)
+		retryAt := c.now().Add(backoff)
+
+		if deadline, ok := ctx.Deadline(); ok && retryAt.After(deadline) {
+			return nil, err // nolint: wrapcheck
+		}
+
+		if serverTimeout > 0 {
+			remaining := serverTimeout - retryAt.Sub(start)
+			if remaining <= 0 {
+				return nil, err // nolint: wrapcheck
+			}
+
+			opts.Payload = withPayloadTimeout(opts.Payload, remaining)
+		}
+
+		logDebugf("Retrying query after error with additional retriable code, attempt %d: %s", retries+1, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err // nolint: wrapcheck
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// payloadTimeout returns the timeout set in the query payload, or 0 if there is none.
+func payloadTimeout(payload map[string]interface{}) time.Duration {
+	timeout, ok := payload["timeout"].(string)
+	if !ok {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0
+	}
+
+	return duration
+}
+
+// withPayloadTimeout returns a copy of the query payload with the timeout set to the given value, the payload itself
+// is not modified as it may be in use by a previous attempt.
+func withPayloadTimeout(payload map[string]interface{}, timeout time.Duration) map[string]interface{} {
+	newPayload := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		newPayload[k] = v
+	}
+
+	newPayload["timeout"] = timeout.String()
+
+	return newPayload
+}
+
+// isAdditionallyRetriable returns whether err contains at least one error which the server did not mark as
+// retriable, with every such error having one of the additional retriable codes.
+func isAdditionallyRetriable(err error, codes map[uint32]struct{}) bool {
+	if len(codes) == 0 {
+		return false
+	}
+
+	var coreErr *gocbcore.ColumnarError
+	if !errors.As(err, &coreErr) {
+		return false
+	}
+
+	var found bool
+
+	for _, desc := range coreErr.Errors {
+		if desc.Retry {
+			continue
+		}
+
+		if _, ok := codes[desc.Code]; !ok {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
@@ -3,6 +3,7 @@ package cbcolumnar_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -169,6 +170,38 @@ func TestDispatchTimeout(t *testing.T) {
 	})
 }
 
+func TestConnectTimeout(t *testing.T) {
+	// We're purposely using an invalid hostname so we need to suppress warnings.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://somenonsense?srv=false",
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		start := time.Now()
+
+		_, err := queryable.ExecuteQuery(ctx, "SELECT 1;", cbcolumnar.NewQueryOptions().SetConnectTimeout(500*time.Millisecond))
+		require.ErrorIs(tt, err, cbcolumnar.ErrConnectTimeout)
+
+		var columnarErr *cbcolumnar.ColumnarError
+
+		require.ErrorAs(tt, err, &columnarErr)
+
+		assert.Less(tt, time.Since(start), 5*time.Second)
+	})
+}
+
 func TestOperationTimeout(t *testing.T) {
 	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
 		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
@@ -347,3 +380,380 @@ func CollectRows[T any](t *testing.T, res *cbcolumnar.QueryResult) []T {
 
 	return actualRows
 }
+
+func TestRowTransform(t *testing.T) {
+	transformErr := errors.New("transform failed") // nolint: err113
+
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 1) AS i SELECT RAW i", cbcolumnar.NewQueryOptions().
+			SetRowTransform(func(row []byte) ([]byte, error) {
+				if string(row) == "1" {
+					return nil, transformErr
+				}
+
+				return []byte("\"transformed\""), nil
+			}))
+		require.NoError(tt, err)
+
+		row := res.NextRow()
+		require.NotNil(tt, row)
+
+		var val string
+		require.NoError(tt, row.ContentAs(&val))
+		assert.Equal(tt, "transformed", val)
+
+		row = res.NextRow()
+		require.NotNil(tt, row)
+
+		err = row.ContentAs(&val)
+		require.ErrorIs(tt, err, transformErr)
+
+		require.Nil(tt, res.NextRow())
+		require.NoError(tt, res.Err())
+	})
+}
+
+func TestRowUnmarshalFunc(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM [1, 1, 2] AS i SELECT RAW i", cbcolumnar.NewQueryOptions().
+			SetCollapseConsecutiveDuplicates(true).
+			SetRowUnmarshalFunc(func(index int, data []byte, out any) error {
+				val, ok := out.(*string)
+				require.True(tt, ok)
+
+				*val = fmt.Sprintf("%d:%s", index, data)
+
+				return nil
+			}))
+		require.NoError(tt, err)
+
+		actualRows := CollectRows[string](t, res)
+		assert.Equal(tt, []string{"0:1", "1:2"}, actualRows)
+
+		require.NoError(tt, res.Err())
+	})
+}
+
+func TestRunConcurrent(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	outcomes, err := cluster.RunConcurrent(ctx, []cbcolumnar.QuerySpec{
+		{Statement: "FROM RANGE(0, 2) AS i SELECT RAW i", Options: nil},
+		{Statement: "SELECT * FROM doesnotexist", Options: nil},
+		{Statement: "SELECT RAW $val", Options: cbcolumnar.NewQueryOptions().SetNamedParameters(map[string]interface{}{"val": 5})},
+	}, 2)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+
+	require.NoError(t, outcomes[0].Err)
+	assert.Len(t, outcomes[0].Rows, 3)
+	assert.NotNil(t, outcomes[0].MetaData)
+
+	require.ErrorIs(t, outcomes[1].Err, cbcolumnar.ErrQuery)
+
+	require.NoError(t, outcomes[2].Err)
+	require.Len(t, outcomes[2].Rows, 1)
+
+	var val int
+	require.NoError(t, outcomes[2].Rows[0].ContentAs(&val))
+	assert.Equal(t, 5, val)
+
+	_, err = cluster.RunConcurrent(ctx, nil, 0)
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestQueryAsync(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	type asyncOutcome struct {
+		res *cbcolumnar.QueryResult
+		err error
+	}
+
+	outcomes := make(chan asyncOutcome, 1)
+	callback := func(res *cbcolumnar.QueryResult, err error) {
+		outcomes <- asyncOutcome{res: res, err: err}
+	}
+
+	err = cluster.QueryAsync(ctx, "FROM RANGE(0, 2) AS i SELECT RAW i", nil, callback)
+	require.NoError(t, err)
+
+	outcome := <-outcomes
+	require.NoError(t, outcome.err)
+
+	rows, _, err := cbcolumnar.BufferQueryResult[int](outcome.res)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, rows)
+
+	err = cluster.QueryAsync(ctx, "SELECT * FROM doesnotexist", nil, callback)
+	require.NoError(t, err)
+
+	outcome = <-outcomes
+	require.ErrorIs(t, outcome.err, cbcolumnar.ErrQuery)
+	assert.Nil(t, outcome.res)
+
+	err = cluster.QueryAsync(ctx, "SELECT 1", nil, nil)
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestCancelByTag(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 10000000) AS i SELECT RAW i",
+			cbcolumnar.NewQueryOptions().SetTags(map[string]string{"tenant": "departing"}))
+		require.NoError(tt, err)
+
+		require.NotNil(tt, res.NextRow())
+
+		assert.Equal(tt, 0, cluster.CancelByTag("tenant", "other"))
+		assert.Equal(tt, 1, cluster.CancelByTag("tenant", "departing"))
+
+		var rows int
+		for row := res.NextRow(); row != nil; row = res.NextRow() {
+			rows++
+		}
+
+		assert.Less(tt, rows, 10000000)
+
+		require.ErrorIs(tt, res.Err(), cbcolumnar.ErrQueryCanceled)
+
+		assert.Equal(tt, 0, cluster.CancelByTag("tenant", "departing"))
+	})
+}
+
+func TestCollapseConsecutiveDuplicates(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM [1, 1, 2, 2, 2, 1] AS i SELECT RAW i",
+			cbcolumnar.NewQueryOptions().SetCollapseConsecutiveDuplicates(true))
+		require.NoError(tt, err)
+
+		actualRows := CollectRows[int](t, res)
+		assert.Equal(tt, []int{1, 2, 1}, actualRows)
+
+		require.NoError(tt, res.Err())
+	})
+}
+
+func TestRowFilter(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 9) AS i SELECT RAW i",
+			cbcolumnar.NewQueryOptions().SetRowFilter(func(row []byte) bool {
+				return string(row) != "0" && len(row) == 1 && (row[0]-'0')%3 == 0
+			}))
+		require.NoError(tt, err)
+
+		actualRows := CollectRows[int](t, res)
+		assert.Equal(tt, []int{3, 6, 9}, actualRows)
+
+		require.NoError(tt, res.Err())
+	})
+}
+
+func TestQueryContextMetaData(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := cluster.ExecuteQuery(ctx, "SELECT 1")
+	require.NoError(t, err)
+
+	_, meta, err := cbcolumnar.BufferQueryResult[map[string]interface{}](res)
+	require.NoError(t, err)
+	assert.Empty(t, meta.QueryContext)
+
+	res, err = cluster.Database(TestOpts.Database).Scope(TestOpts.Scope).ExecuteQuery(ctx, "SELECT 1")
+	require.NoError(t, err)
+
+	_, meta, err = cbcolumnar.BufferQueryResult[map[string]interface{}](res)
+	require.NoError(t, err)
+	assert.Equal(t, "default:"+cbcolumnar.EscapeIdentifier(TestOpts.Database)+"."+
+		cbcolumnar.EscapeIdentifier(TestOpts.Scope), meta.QueryContext)
+}
+
+func TestMaxRowBytes(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM [\"a\", \"abcdefghijklmnopqrstuvwxyz\", \"b\"] AS i SELECT RAW i",
+			cbcolumnar.NewQueryOptions().SetMaxRowBytes(10))
+		require.NoError(tt, err)
+
+		row := res.NextRow()
+		require.NotNil(tt, row)
+
+		require.Nil(tt, res.NextRow())
+		require.ErrorIs(tt, res.Err(), cbcolumnar.ErrRowTooLarge)
+	})
+}
+
+func TestCount(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	count, err := cluster.Count(ctx, "FROM RANGE(0, 99) AS i SELECT RAW i;")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), count)
+
+	count, err = cluster.Database(TestOpts.Database).Scope(TestOpts.Scope).Count(ctx, "FROM RANGE(0, 9) AS i WHERE i > 4 SELECT i")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+}
+
+func TestDefaultOperationTimeout(t *testing.T) {
+	// We're purposely using an invalid hostname so we need to suppress warnings.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://somenonsense?srv=false",
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions().SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetDefaultOperationTimeout(500*time.Millisecond)),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		start := time.Now()
+
+		_, err := queryable.ExecuteQuery(context.Background(), "SELECT 1;")
+		require.Error(tt, err)
+
+		assert.Less(tt, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestClusterWithDefaults(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	withDefaults := cluster.WithDefaults(cbcolumnar.NewQueryOptions().SetNamedParameters(map[string]interface{}{"val": 5}))
+
+	ExecuteQueryAgainst(t, []Queryable{withDefaults, withDefaults.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "SELECT RAW $val")
+		require.NoError(tt, err)
+
+		assert.Equal(tt, []int{5}, CollectRows[int](tt, res))
+
+		res, err = queryable.ExecuteQuery(ctx, "SELECT RAW $val",
+			cbcolumnar.NewQueryOptions().SetNamedParameters(map[string]interface{}{"val": 10}))
+		require.NoError(tt, err)
+
+		assert.Equal(tt, []int{10}, CollectRows[int](tt, res))
+	})
+}
+
+func TestQueryFromContextWithDefaults(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	withDefaults := cluster.WithDefaults(cbcolumnar.NewQueryOptions().SetNamedParameters(map[string]interface{}{"val": 5}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ctx = cbcolumnar.ContextWithNamespace(ctx, cbcolumnar.NewNamespaceOptions(TestOpts.Database, TestOpts.Scope))
+
+	res, err := withDefaults.QueryFromContext(ctx, "SELECT RAW $val")
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{5}, CollectRows[int](t, res))
+}
@@ -3,12 +3,15 @@ package cbcolumnar
 // Scope represents a Columnar scope.
 type Scope struct {
 	client scopeClient
+
+	defaultQueryOptions *QueryOptions
 }
 
 // Scope creates a new Scope instance.
 func (d *Database) Scope(name string) *Scope {
 	return &Scope{
-		d.client.Scope(name),
+		client:              d.client.Scope(name),
+		defaultQueryOptions: d.defaultQueryOptions,
 	}
 }
 
@@ -0,0 +1,96 @@
+package cbcolumnar
+
+import (
+	"strings"
+	"unicode"
+)
+
+// subqueryStatement returns the statement in a form which can be wrapped within a subquery, with any trailing
+// semicolons and comments removed. An error is returned if the statement is not a single query, as wrapping
+// any other statement would produce an invalid, or different, statement.
+// Like FingerprintStatement, this is not a full parser, the server remains responsible for validating the query.
+func subqueryStatement(statement string) (string, error) {
+	runes := []rune(statement)
+
+	var firstWord string
+
+	leading := true
+	terminated := false
+	end := 0
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+
+			i += 2
+
+			continue
+		}
+
+		if r == ';' {
+			terminated = true
+			i++
+
+			continue
+		}
+
+		if terminated {
+			return "", invalidArgumentError{
+				ArgumentName: "statement",
+				Reason:       "must be a single query",
+			}
+		}
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			i = skipQuoted(runes, i)
+			leading = false
+		case isIdentifierRune(r):
+			start := i
+			for i < len(runes) && isIdentifierRune(runes[i]) {
+				i++
+			}
+
+			if leading {
+				firstWord = string(runes[start:i])
+				leading = false
+			}
+		default:
+			// Queries can be parenthesized, so the first word is the first one following any opening parentheses.
+			if r != '(' {
+				leading = false
+			}
+
+			i++
+		}
+
+		end = i
+	}
+
+	switch strings.ToUpper(firstWord) {
+	case "SELECT", "WITH", "FROM":
+	default:
+		return "", invalidArgumentError{
+			ArgumentName: "statement",
+			Reason:       "must be a query beginning with SELECT, WITH or FROM",
+		}
+	}
+
+	return strings.TrimSpace(string(runes[:end])), nil
+}
@@ -0,0 +1,74 @@
+package cbcolumnar
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templateSlotRegexp matches the {{identifier}} slots of a Template, allowing whitespace around the slot name.
+var templateSlotRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Template is a statement template containing {{name}} slots which are filled with identifiers, such as database,
+// scope or collection names, which cannot be provided as query parameters. Identifiers are escaped using
+// EscapeIdentifier when the template is rendered, values should still be provided as query parameters.
+// Note that a SQL++ multiset constructor containing only a single identifier, such as {{ x }}, is treated as a slot.
+type Template struct {
+	statement string
+	slots     []string
+}
+
+// NewTemplate creates a new Template from the statement.
+func NewTemplate(statement string) *Template {
+	var slots []string
+
+	seen := make(map[string]struct{})
+
+	for _, match := range templateSlotRegexp.FindAllStringSubmatch(statement, -1) {
+		if _, ok := seen[match[1]]; ok {
+			continue
+		}
+
+		seen[match[1]] = struct{}{}
+		slots = append(slots, match[1])
+	}
+
+	return &Template{
+		statement: statement,
+		slots:     slots,
+	}
+}
+
+// Slots returns the names of the slots within the template, in the order in which they first appear.
+func (t *Template) Slots() []string {
+	return append([]string(nil), t.slots...)
+}
+
+// Render produces the statement with each slot replaced by the escaped identifier with the same name.
+// An error wrapping ErrInvalidArgument is returned if any slot has no identifier, identifiers which do not
+// correspond to a slot are ignored.
+func (t *Template) Render(identifiers map[string]string) (string, error) {
+	var missing []string
+
+	for _, slot := range t.slots {
+		if _, ok := identifiers[slot]; !ok {
+			missing = append(missing, slot)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		return "", invalidArgumentError{
+			ArgumentName: "identifiers",
+			Reason:       fmt.Sprintf("no identifier provided for slots %s", strings.Join(missing, ", ")),
+		}
+	}
+
+	return templateSlotRegexp.ReplaceAllStringFunc(t.statement, func(slot string) string {
+		name := templateSlotRegexp.FindStringSubmatch(slot)[1]
+
+		return EscapeIdentifier(identifiers[name])
+	}), nil
+}
@@ -0,0 +1,33 @@
+package cbcolumnar_test
+
+import (
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	template := cbcolumnar.NewTemplate("SELECT * FROM {{database}}.{{ scope }}.{{collection}} WHERE name = $name " +
+		"AND {{collection}}.id IS NOT MISSING")
+
+	assert.Equal(t, []string{"database", "scope", "collection"}, template.Slots())
+
+	statement, err := template.Render(map[string]string{
+		"database":   "travel-sample",
+		"scope":      "inventory",
+		"collection": "air`line",
+		"unused":     "ignored",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `travel-sample`.`inventory`.`air``line` WHERE name = $name "+
+		"AND `air``line`.id IS NOT MISSING", statement)
+
+	_, err = template.Render(map[string]string{"database": "travel-sample"})
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	statement, err = cbcolumnar.NewTemplate("SELECT {{ 1, 2 }}").Render(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT {{ 1, 2 }}", statement)
+}
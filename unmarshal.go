@@ -1,6 +1,11 @@
 package cbcolumnar
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
 
 // Unmarshaler provides a way to unmarshal data into a Go value.
 type Unmarshaler interface {
@@ -9,11 +14,24 @@ type Unmarshaler interface {
 }
 
 // JSONUnmarshaler is an Unmarshaler that performs JSON unmarshalling.
-type JSONUnmarshaler struct{}
+type JSONUnmarshaler struct {
+	disallowUnknownFields bool
+}
 
 // NewJSONUnmarshaler creates a new JSONUnmarshaler.
+// By default, fields which are not present in the value being unmarshalled into are ignored.
 func NewJSONUnmarshaler() *JSONUnmarshaler {
-	return &JSONUnmarshaler{}
+	return &JSONUnmarshaler{
+		disallowUnknownFields: false,
+	}
+}
+
+// SetDisallowUnknownFields sets whether unmarshalling should fail when the data contains object keys which do not
+// match any field of the destination struct, as with json.Decoder.DisallowUnknownFields.
+func (ju *JSONUnmarshaler) SetDisallowUnknownFields(disallow bool) *JSONUnmarshaler {
+	ju.disallowUnknownFields = disallow
+
+	return ju
 }
 
 // Unmarshal unmarshals the data into the provided value.
@@ -24,7 +42,13 @@ func (ju *JSONUnmarshaler) Unmarshal(data []byte, v interface{}) error {
 		return nil
 	}
 
-	err := json.Unmarshal(data, v)
+	var err error
+	if ju.disallowUnknownFields {
+		err = unmarshalJSONStrict(data, v)
+	} else {
+		err = json.Unmarshal(data, v)
+	}
+
 	if err != nil {
 		return unmarshalError{
 			Reason: err.Error(),
@@ -33,3 +57,26 @@ func (ju *JSONUnmarshaler) Unmarshal(data []byte, v interface{}) error {
 
 	return nil
 }
+
+func unmarshalJSONStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+
+	// Unlike json.Unmarshal the decoder permits trailing data, so this is checked for explicitly.
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid data after top-level value") // nolint: err113
+	}
+
+	return nil
+}
+
+// ParameterMarshaler provides a way to marshal query parameters, see QueryOptions.ParameterMarshaler.
+type ParameterMarshaler interface {
+	// Marshal marshals the value into JSON.
+	Marshal(interface{}) ([]byte, error)
+}
@@ -0,0 +1,32 @@
+package cbcolumnar
+
+import (
+	"context"
+)
+
+type unmarshalerContextKey struct{}
+
+// ContextWithUnmarshaler returns a copy of ctx which carries the provided Unmarshaler, which is used to decode the rows
+// of queries executed with the returned context. This allows, for example, middleware to control how rows are decoded
+// without changing the options passed to each query.
+// The unmarshaler used for a query is chosen in the following order of precedence:
+//  1. QueryOptions.Unmarshaler, including any set via Cluster.WithDefaults.
+//  2. The Unmarshaler carried by the context.
+//  3. ClusterOptions.Unmarshaler, or a JSONUnmarshaler if that is not set.
+func ContextWithUnmarshaler(ctx context.Context, unmarshaler Unmarshaler) context.Context {
+	return context.WithValue(ctx, unmarshalerContextKey{}, unmarshaler)
+}
+
+// UnmarshalerFromContext returns the Unmarshaler stored in ctx by ContextWithUnmarshaler, if any.
+func UnmarshalerFromContext(ctx context.Context) (Unmarshaler, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	unmarshaler, ok := ctx.Value(unmarshalerContextKey{}).(Unmarshaler)
+	if !ok || unmarshaler == nil {
+		return nil, false
+	}
+
+	return unmarshaler, true
+}
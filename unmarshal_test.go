@@ -0,0 +1,68 @@
+package cbcolumnar_test
+
+import (
+	"context"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONUnmarshalerDisallowUnknownFields(t *testing.T) {
+	type airline struct {
+		Name string `json:"name"`
+	}
+
+	row := []byte(`{"name":"United","unknown":1}`)
+
+	t.Run("Unknown Fields Ignored", func(tt *testing.T) {
+		var val airline
+
+		err := cbcolumnar.NewJSONUnmarshaler().Unmarshal(row, &val)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "United", val.Name)
+	})
+
+	t.Run("Unknown Fields Rejected", func(tt *testing.T) {
+		res := cbcolumnar.NewQueryResult(NewMockRowReader([]string{string(row)}, nil),
+			cbcolumnar.NewJSONUnmarshaler().SetDisallowUnknownFields(true))
+
+		var val airline
+
+		err := res.NextRow().ContentAs(&val)
+		require.ErrorIs(tt, err, cbcolumnar.ErrUnmarshal)
+	})
+
+	t.Run("Known Fields Accepted", func(tt *testing.T) {
+		var val airline
+
+		err := cbcolumnar.NewJSONUnmarshaler().SetDisallowUnknownFields(true).Unmarshal([]byte(`{"name":"United"}`), &val)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "United", val.Name)
+	})
+
+	t.Run("Trailing Data Rejected", func(tt *testing.T) {
+		var val airline
+
+		err := cbcolumnar.NewJSONUnmarshaler().SetDisallowUnknownFields(true).Unmarshal([]byte(`{"name":"United"} {}`), &val)
+		require.ErrorIs(tt, err, cbcolumnar.ErrUnmarshal)
+	})
+}
+
+func TestUnmarshalerFromContext(t *testing.T) {
+	_, ok := cbcolumnar.UnmarshalerFromContext(context.Background())
+	assert.False(t, ok)
+
+	unmarshaler := cbcolumnar.NewJSONUnmarshaler().SetDisallowUnknownFields(true)
+	ctx := cbcolumnar.ContextWithUnmarshaler(context.Background(), unmarshaler)
+
+	fromCtx, ok := cbcolumnar.UnmarshalerFromContext(ctx)
+	require.True(t, ok)
+	assert.Same(t, unmarshaler, fromCtx)
+
+	_, ok = cbcolumnar.UnmarshalerFromContext(cbcolumnar.ContextWithUnmarshaler(context.Background(), nil))
+	assert.False(t, ok)
+}